@@ -32,40 +32,39 @@ func CourtCaseCheck(caseNumber, pdfURL string) check.Check {
 	return check.Check{
 		Name:     "court-case-" + strings.ReplaceAll(caseNumber, " ", "-"),
 		Interval: 5 * time.Minute,
-		Run: func(ctx context.Context, c *claude.Client) (check.CheckResult, error) {
+		Run: func(ctx context.Context, c claude.Analyzer) (check.CheckResult, error) {
 			if c == nil {
 				return check.CheckResult{}, fmt.Errorf("claude client required for this check")
 			}
 
 			// Fetch PDF
-			pdf, err := fetchURL(ctx, pdfURL)
+			pdf, info, err := claude.FetchURL(ctx, pdfURL)
 			if err != nil {
 				return check.CheckResult{}, fmt.Errorf("fetch PDF: %w", err)
 			}
 
-			// Ask Claude to analyze
+			// Ask Claude for a structured answer
 			prompt := fmt.Sprintf(
-				"Find case %s in this PDF. Look at the 'Ready for Pickup' column. "+
-					"If there is an X in that column for this case, respond with exactly: Ready. "+
-					"If there is no X, or the case is not listed, respond with exactly: Not Ready. "+
-					"Say nothing else.",
+				"Find case %s in this PDF and look at the 'Ready for Pickup' column for that case.",
 				caseNumber,
 			)
 
-			response, err := c.Analyze(ctx, prompt, pdf)
-			if err != nil {
+			var answer struct {
+				Listed bool `json:"listed" description:"true if the case appears in the PDF"`
+				Ready  bool `json:"ready" description:"true if there is an X in the Ready for Pickup column for this case"`
+			}
+			if err := claude.AnalyzeJSON(ctx, c, prompt, pdf, &answer); err != nil {
 				return check.CheckResult{}, fmt.Errorf("claude analysis: %w", err)
 			}
 
-			response = strings.TrimSpace(response)
-
-			if strings.HasPrefix(response, "Ready") {
+			if answer.Listed && answer.Ready {
 				return check.CheckResult{
 					ShouldAlert: true,
 					Title:       "Case Ready!",
 					Message:     fmt.Sprintf("%s is ready for pickup", caseNumber),
 					Priority:    check.PriorityHigh,
 					Tags:        []string{"court", "urgent"},
+					Metadata:    info.Metadata(),
 				}, nil
 			}
 
@@ -84,7 +83,11 @@ func WebsiteUpCheck(url string) check.Check {
 	return check.Check{
 		Name:     "website-" + name,
 		Interval: 1 * time.Minute,
-		Run: func(ctx context.Context, _ *claude.Client) (check.CheckResult, error) {
+		// Ride out single network blips and a site bouncing up and down
+		FailureThreshold: 3,
+		SuccessThreshold: 2,
+		Flap:             check.FlapPolicy{Window: 30 * time.Minute, MaxChanges: 6},
+		Run: func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
 			client := &http.Client{Timeout: 10 * time.Second}
 
 			req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -122,7 +125,7 @@ func BitcoinPriceCheck(threshold float64) check.Check {
 	return check.Check{
 		Name:     "btc-price",
 		Interval: 10 * time.Minute,
-		Run: func(ctx context.Context, _ *claude.Client) (check.CheckResult, error) {
+		Run: func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
 			price, err := fetchBTCPrice(ctx)
 			if err != nil {
 				return check.CheckResult{}, err
@@ -147,28 +150,6 @@ func BitcoinPriceCheck(threshold float64) check.Check {
 	}
 }
 
-// fetchURL fetches content from a URL
-func fetchURL(ctx context.Context, url string) ([]byte, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	return io.ReadAll(resp.Body)
-}
-
 // fetchBTCPrice fetches current BTC price from CoinGecko
 func fetchBTCPrice(ctx context.Context) (float64, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
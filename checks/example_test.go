@@ -0,0 +1,101 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/claude/claudetest"
+)
+
+func TestCourtCaseCheck(t *testing.T) {
+	pdf := []byte("%PDF-1.4\nCASE-123456 | X\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		fake    *claudetest.Fake
+		alert   bool
+		wantErr error
+	}{
+		{
+			name:  "ready",
+			fake:  claudetest.NewFake().On(`CASE-123456`, `{"listed": true, "ready": true}`),
+			alert: true,
+		},
+		{
+			name: "listed but not ready",
+			fake: claudetest.NewFake().On(`CASE-123456`, `{"listed": true, "ready": false}`),
+		},
+		{
+			name: "not listed",
+			fake: claudetest.NewFake().On(`CASE-123456`, "The case isn't in this PDF:\n```json\n{\"listed\": false, \"ready\": false}\n```"),
+		},
+		{
+			name:  "corrected after a malformed answer",
+			fake:  claudetest.NewFake().On(`CASE-123456`, `ready: yes`, `{"listed": true, "ready": true}`),
+			alert: true,
+		},
+		{
+			name:    "malformed answers",
+			fake:    claudetest.NewFake().On(`CASE-123456`, `{"listed": "yes"}`),
+			wantErr: claude.ErrMalformedJSON,
+		},
+		{
+			name:    "claude fails",
+			fake:    claudetest.NewFake().OnError(`.`, claude.ErrRateLimited),
+			wantErr: claude.ErrRateLimited,
+		},
+	}
+
+	c := CourtCaseCheck("CASE-123456", srv.URL)
+	if c.Name != "court-case-CASE-123456" {
+		t.Errorf("name = %q", c.Name)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.Run(context.Background(), tt.fake)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.ShouldAlert != tt.alert {
+				t.Fatalf("ShouldAlert = %t, want %t", result.ShouldAlert, tt.alert)
+			}
+			if tt.alert && (result.Level() != check.StatusCritical || result.Message != "CASE-123456 is ready for pickup") {
+				t.Errorf("result = %s %q", result.Level(), result.Message)
+			}
+
+			calls := tt.fake.Calls()
+			if len(calls) == 0 {
+				t.Fatal("claude was not called")
+			}
+			if call := calls[0]; call.Method != "Analyze" || !bytes.Equal(call.Content, pdf) || !strings.Contains(call.Prompt, "Ready for Pickup") {
+				t.Errorf("first call = %s %q with %d bytes", call.Method, call.Prompt, len(call.Content))
+			}
+		})
+	}
+}
+
+func TestCourtCaseCheckNeedsClaude(t *testing.T) {
+	if _, err := CourtCaseCheck("CASE-1", "http://127.0.0.1:0").Run(context.Background(), nil); err == nil {
+		t.Error("ran without a claude client")
+	}
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/murr/check-and-ping/checks"
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/config"
+	"github.com/murr/check-and-ping/internal/metrics"
+	"github.com/murr/check-and-ping/internal/notifier"
+	"github.com/murr/check-and-ping/internal/scheduler"
+	"github.com/murr/check-and-ping/internal/server"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+const defaultConfigPath = "config.yaml"
+
+const usage = `Usage: checkandping [command] [--config path]
+
+Commands:
+  run       Run checks on their intervals until interrupted (default)
+  once      Run every check one time; exit 1 if any alert fired
+  list      List compiled and config-declared checks with their schedules
+  validate  Load and validate the config file
+  ack       Acknowledge a check's open alert (stops reminders)
+  snooze    Mute a check's notifications for a duration
+  silence   Add, list or expire silences matching checks and tags
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to a subcommand and returns the process exit code
+func run(args []string) int {
+	// No command (or only flags) means "run", matching the Dockerfile CMD
+	command := "run"
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	// These take their own flags and arguments
+	switch command {
+	case "ack", "snooze", "silence":
+		return cmdSilence(command, args)
+	}
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	configPath := fs.String("config", defaultConfigPath, "path to config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := log.Default()
+
+	switch command {
+	case "run":
+		return cmdRun(*configPath, logger)
+	case "once":
+		return cmdOnce(*configPath, logger)
+	case "list":
+		return cmdList(*configPath)
+	case "validate":
+		return cmdValidate(*configPath)
+	case "help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", command, usage)
+		return 2
+	}
+}
+
+// cmdRun starts the scheduler and blocks until SIGINT or SIGTERM. The config
+// is reloaded on SIGHUP or when the file changes.
+func cmdRun(configPath string, logger *log.Logger) int {
+	app, err := setup(configPath, logger)
+	if err != nil {
+		logger.Printf("error: %v", err)
+		return 1
+	}
+	defer app.Close()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	if app.config.Server.Enabled() {
+		srv := server.New(app.scheduler,
+			server.WithToken(app.config.Server.Token),
+			server.WithLogger(logger),
+			server.WithMetrics(app.metrics.Handler()))
+		if err := srv.Start(app.config.Server.Listen); err != nil {
+			logger.Printf("error: status server: %v", err)
+			return 1
+		}
+		defer srv.Stop()
+		logger.Printf("status API listening on %s", app.config.Server.Listen)
+	}
+
+	logger.Printf("starting %d checks, notifying via %s", app.checkCount, app.notifier.Name())
+	app.startOutbox(context.Background())
+	app.scheduler.Start(context.Background())
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	changed := make(chan struct{}, 1)
+	go watchConfig(watchCtx, configPath, configPollInterval, changed)
+
+	for {
+		select {
+		case <-changed:
+			app.reloadConfig(configPath)
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				app.reloadConfig(configPath)
+				continue
+			}
+			logger.Printf("received %s, shutting down", sig)
+			app.scheduler.Stop()
+			return 0
+		}
+	}
+}
+
+// cmdOnce runs every check a single time
+func cmdOnce(configPath string, logger *log.Logger) int {
+	app, err := setup(configPath, logger)
+	if err != nil {
+		logger.Printf("error: %v", err)
+		return 1
+	}
+	defer app.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if alerts := app.scheduler.RunOnce(ctx); alerts > 0 {
+		logger.Printf("%d of %d checks alerted", alerts, app.checkCount)
+		return 1
+	}
+
+	return 0
+}
+
+// cmdList prints the compiled-in checks and any declared in the config file.
+// A missing config file is not an error; only compiled checks are listed.
+func cmdList(configPath string) int {
+	all := checks.All()
+	if _, err := os.Stat(configPath); err == nil {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+			return 1
+		}
+		if all, err = allChecks(cfg, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid checks: %v\n", err)
+			return 1
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCHEDULE")
+	for _, c := range all {
+		schedule := "every " + c.Interval.String()
+		if c.Schedule != "" {
+			schedule = c.Schedule
+		}
+		fmt.Fprintf(w, "%s\t%s\n", c.Name, schedule)
+	}
+	w.Flush()
+	return 0
+}
+
+// cmdValidate loads and validates the config file
+func cmdValidate(configPath string) int {
+	if _, err := loadConfig(configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s: OK\n", configPath)
+	return 0
+}
+
+// app holds the components wired together from config
+type app struct {
+	config     *config.Config
+	scheduler  *scheduler.Scheduler
+	checks     checkRegistry // the scheduler, as changed by reloads
+	notifier   fanoutNotifier
+	outbox     *notifier.Outbox
+	channels   []configuredNotifier
+	state      state.State
+	pings      state.Pings
+	claude     *claude.Client
+	metrics    *metrics.Registry
+	logger     *log.Logger
+	checkCount int
+}
+
+// Close releases resources held by the app
+func (a *app) Close() error {
+	if a.outbox != nil {
+		a.outbox.Stop()
+	}
+	if a.claude != nil && a.claude.CacheEnabled() {
+		stats := a.claude.CacheStats()
+		a.logger.Printf("Claude cache: %d hits, %d misses", stats.Hits, stats.Misses)
+	}
+	return a.state.Close()
+}
+
+// startOutbox retries notifications that fail temporarily in the
+// background, queueing them in the state backend
+func (a *app) startOutbox(ctx context.Context) {
+	store, ok := a.state.(state.Outbox)
+	if !ok {
+		return
+	}
+
+	o := notifier.NewOutbox(store, a.config.Retry.Policy(), a.logger)
+	if history, ok := a.state.(state.History); ok {
+		o.OnSend(recordNotification(history, a.logger))
+	}
+	o.SetMetrics(a.metrics)
+	a.notifier.SetOutbox(o)
+	o.Start(ctx)
+	a.outbox = o
+}
+
+// setup loads the config and wires the scheduler with all registered checks
+func setup(configPath string, logger *log.Logger) (*app, error) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := buildChannels(cfg.Notifications, nil)
+	if err != nil {
+		return nil, err
+	}
+	n, err := buildNotifier(cfg, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := buildState(cfg.State)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := metrics.NewRegistry()
+	instrumentNotifier(n, st, reg, logger)
+
+	pings, _ := st.(state.Pings)
+	all, err := allChecks(cfg, pings)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	opts := []scheduler.Option{scheduler.WithRenotify(cfg.Renotify.Policy()), scheduler.WithMetrics(reg)}
+	if cfg.CheckTimeout > 0 {
+		opts = append(opts, scheduler.WithDefaultTimeout(cfg.CheckTimeout))
+	}
+
+	cl := buildClaude(cfg.Claude, st, reg, logger)
+	// Pass a nil interface, not a nil *Client, so checks can test for it
+	var analyzer claude.Analyzer
+	if cl != nil {
+		analyzer = cl
+	}
+	sched := scheduler.New(analyzer, n, st, logger, opts...)
+	for _, c := range all {
+		if err := sched.Register(c); err != nil {
+			st.Close()
+			return nil, err
+		}
+	}
+
+	return &app{
+		config:     cfg,
+		scheduler:  sched,
+		checks:     sched,
+		notifier:   n,
+		channels:   channels,
+		pings:      pings,
+		state:      st,
+		claude:     cl,
+		metrics:    reg,
+		logger:     logger,
+		checkCount: len(all),
+	}, nil
+}
+
+// allChecks returns the compiled-in checks followed by those declared in
+// config. Heartbeat checks read their pings from pings.
+func allChecks(cfg *config.Config, pings state.Pings) ([]check.Check, error) {
+	declared, err := cfg.BuildChecks(pings)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(checks.All(), declared...)
+
+	seen := make(map[string]bool, len(all))
+	for _, c := range all {
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate check name: %s", c.Name)
+		}
+		seen[c.Name] = true
+	}
+
+	return all, nil
+}
+
+// loadConfig reads and validates the config file
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+	return cfg, nil
+}
+
+// buildClaude creates the Claude client, or nil if Claude is disabled
+func buildClaude(cfg config.ClaudeConfig, st state.State, reg *metrics.Registry, logger *log.Logger) *claude.Client {
+	if cfg.Disabled {
+		return nil
+	}
+
+	opts := []claude.ClientOption{claude.WithLogger(logger), claude.WithMetrics(reg)}
+	if cfg.CLIPath != "" {
+		opts = append(opts, claude.WithCLIPath(cfg.CLIPath))
+	}
+	if cfg.Model != "" {
+		opts = append(opts, claude.WithModel(cfg.Model))
+	}
+	if cfg.Backend == "api" {
+		opts = append(opts, claude.WithAPIKey(cfg.APIKey), claude.WithSystemPrompt(cfg.SystemPrompt))
+		if cfg.APIURL != "" {
+			opts = append(opts, claude.WithAPIURL(cfg.APIURL))
+		}
+		if cfg.MaxTokens > 0 {
+			opts = append(opts, claude.WithMaxTokens(cfg.MaxTokens))
+		}
+	}
+	if cfg.MaxConcurrent > 0 || cfg.MaxRequests > 0 {
+		opts = append(opts, claude.WithLimits(claude.Limits{
+			MaxConcurrent: cfg.MaxConcurrent,
+			MaxRequests:   cfg.MaxRequests,
+			Window:        cfg.RequestWindow,
+			MaxWait:       cfg.MaxWait,
+		}))
+	}
+	switch cfg.Cache.Type {
+	case "memory":
+		opts = append(opts, claude.WithCache(claude.NewMemoryCache(), cfg.Cache.TTL))
+	case "sqlite":
+		if cache, ok := st.(claude.Cache); ok {
+			opts = append(opts, claude.WithCache(cache, cfg.Cache.TTL))
+		}
+	}
+
+	c := claude.NewClient(opts...)
+	if cfg.Backend != "api" {
+		if err := c.ValidateCLI(); err != nil {
+			// Not fatal: checks that don't use Claude still work
+			logger.Printf("warning: %v", err)
+		}
+	}
+
+	return c
+}
+
+// fanoutNotifier is a notifier that sends to several channels and reports each attempt
+type fanoutNotifier interface {
+	notifier.Fanout
+	OnSend(hook notifier.SendHook)
+	SetMetrics(reg *metrics.Registry)
+	SetOutbox(o *notifier.Outbox)
+}
+
+// configuredNotifier is a notifier with the config it was built from, so a
+// reload can keep the notifiers whose config didn't change
+type configuredNotifier struct {
+	config   config.NotificationConfig
+	notifier notifier.Notifier
+}
+
+// buildNotifier creates the top-level notifier: a Router when routing is
+// configured, otherwise a Multi that sends everything everywhere.
+// Falls back to stdout when nothing is configured.
+func buildNotifier(cfg *config.Config, channels []configuredNotifier) (fanoutNotifier, error) {
+	multi := buildMulti(channels)
+
+	if !cfg.Routing.Enabled() {
+		return multi, nil
+	}
+
+	routes, err := cfg.Routing.BuildRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	return notifier.NewRouter(routes, cfg.Routing.Default, multi.Notifiers()...), nil
+}
+
+// buildChannels creates a notifier for each notification config, reusing
+// one from previous when its config is unchanged. Notifiers with a
+// configured name are wrapped so Name returns it.
+func buildChannels(configs []config.NotificationConfig, previous []configuredNotifier) ([]configuredNotifier, error) {
+	channels := make([]configuredNotifier, 0, len(configs))
+	used := make([]bool, len(previous))
+
+	for i, nc := range configs {
+		if j := unchangedNotifier(nc, previous, used); j >= 0 {
+			used[j] = true
+			channels = append(channels, previous[j])
+			continue
+		}
+
+		n, err := buildOne(nc)
+		if err != nil {
+			return nil, fmt.Errorf("notification[%d]: %w", i, err)
+		}
+		if nc.Name != "" {
+			n = notifier.Named(nc.Name, n)
+		}
+		channels = append(channels, configuredNotifier{config: nc, notifier: n})
+	}
+
+	return channels, nil
+}
+
+// unchangedNotifier returns the index of an unused notifier in previous
+// built from the same config as nc, or -1
+func unchangedNotifier(nc config.NotificationConfig, previous []configuredNotifier, used []bool) int {
+	for i, p := range previous {
+		if !used[i] && reflect.DeepEqual(p.config, nc) {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildMulti creates a Multi notifier that sends to every channel, or to
+// stdout if there are none
+func buildMulti(channels []configuredNotifier) *notifier.Multi {
+	multi := notifier.NewMulti()
+	for _, c := range channels {
+		multi.Add(c.notifier)
+	}
+
+	if len(channels) == 0 {
+		multi.Add(notifier.NewStdout())
+	}
+
+	return multi
+}
+
+// buildOne creates a single notifier from its config
+func buildOne(nc config.NotificationConfig) (notifier.Notifier, error) {
+	switch nc.Type {
+	case "stdout":
+		return notifier.NewStdout(), nil
+	case "ntfy":
+		var opts []notifier.NtfyOption
+		if nc.Server != "" {
+			opts = append(opts, notifier.WithNtfyServer(nc.Server))
+		}
+		return notifier.NewNtfy(nc.Topic, opts...), nil
+	case "twilio":
+		return notifier.NewTwilio(nc.AccountSID, nc.AuthToken, nc.From, nc.To), nil
+	case "sendgrid":
+		var opts []notifier.SendGridOption
+		if nc.FromName != "" {
+			opts = append(opts, notifier.WithSendGridFromName(nc.FromName))
+		}
+		return notifier.NewSendGrid(nc.APIKey, nc.From, nc.To, opts...), nil
+	case "webhook":
+		var opts []notifier.WebhookOption
+		if nc.Method != "" {
+			opts = append(opts, notifier.WithWebhookMethod(nc.Method))
+		}
+		for k, v := range nc.Headers {
+			opts = append(opts, notifier.WithWebhookHeader(k, v))
+		}
+		if len(nc.SuccessStatus) > 0 {
+			opts = append(opts, notifier.WithWebhookSuccessStatus(nc.SuccessStatus...))
+		}
+		return notifier.NewWebhook(nc.URL, nc.Template, opts...)
+	case "slack":
+		var opts []notifier.SlackOption
+		if nc.CheckURL != "" {
+			opts = append(opts, notifier.WithSlackCheckURL(nc.CheckURL))
+		}
+		if nc.APIURL != "" {
+			opts = append(opts, notifier.WithSlackAPIURL(nc.APIURL))
+		}
+		if nc.Token != "" {
+			return notifier.NewSlackBot(nc.Token, nc.Channel, opts...), nil
+		}
+		return notifier.NewSlackWebhook(nc.URL, opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown type: %s", nc.Type)
+	}
+}
+
+// buildState creates the configured state backend
+func buildState(cfg config.StateConfig) (state.State, error) {
+	switch cfg.Type {
+	case "sqlite":
+		var opts []state.SQLiteOption
+		if cfg.Retention != 0 {
+			opts = append(opts, state.WithRetention(cfg.Retention))
+		}
+		return state.NewSQLite(cfg.DBPath, opts...)
+	case "memory", "":
+		return state.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown state type: %s", cfg.Type)
+	}
+}
+
+// instrumentNotifier records n's delivery attempts in history, if the state
+// backend keeps it, and counts them in reg
+func instrumentNotifier(n fanoutNotifier, st state.State, reg *metrics.Registry, logger *log.Logger) {
+	if history, ok := st.(state.History); ok {
+		n.OnSend(recordNotification(history, logger))
+	}
+	n.SetMetrics(reg)
+}
+
+// recordNotification returns a send hook that logs each delivery attempt to history
+func recordNotification(history state.History, logger *log.Logger) notifier.SendHook {
+	return func(name string, alert check.Alert, err error) {
+		record := state.NotificationRecord{
+			CheckName: alert.CheckName,
+			Notifier:  name,
+			Title:     alert.Title,
+			Recovery:  alert.Recovery,
+			SentAt:    time.Now(),
+			Success:   err == nil,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if err := history.RecordNotification(record); err != nil {
+			logger.Printf("[%s] failed to record notification: %v", alert.CheckName, err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/config"
+	"github.com/murr/check-and-ping/internal/notifier"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 2 * time.Second
+
+// checkRegistry is the part of the scheduler a reload changes
+type checkRegistry interface {
+	Register(c check.Check) error
+	Unregister(name string) error
+	SetNotifier(n notifier.Notifier)
+}
+
+// watchConfig polls the config file until ctx ends and signals changed
+// once its modification time or size changes. The change is only reported
+// when the file looks the same on two polls in a row, so a file that is
+// still being written isn't loaded half-finished.
+func watchConfig(ctx context.Context, path string, interval time.Duration, changed chan<- struct{}) {
+	loaded, _ := os.Stat(path)
+	var pending os.FileInfo
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// Editors may briefly remove the file while saving
+			pending = nil
+			continue
+		}
+		if sameFile(info, loaded) {
+			pending = nil
+			continue
+		}
+		if !sameFile(info, pending) {
+			pending = info
+			continue
+		}
+		loaded, pending = info, nil
+
+		select {
+		case changed <- struct{}{}:
+		default:
+			// A reload is already pending
+		}
+	}
+}
+
+// sameFile reports whether a and b have the same modification time and size
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && b != nil && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// reload re-reads and validates the config file, then applies the
+// difference: changed notifiers and routing are rebuilt, and declared
+// checks are added, removed or rescheduled. Unchanged checks keep running
+// undisturbed. Settings that need a restart are reported and left as they
+// were. An invalid config changes nothing.
+func (a *app) reload(configPath string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	// Build everything first so a config that fails here leaves the
+	// running scheduler untouched
+	all, err := allChecks(cfg, a.pings)
+	if err != nil {
+		return err
+	}
+	declared, err := cfg.BuildChecks(a.pings)
+	if err != nil {
+		return err
+	}
+
+	notifiersChanged := !reflect.DeepEqual(a.config.Notifications, cfg.Notifications) ||
+		!reflect.DeepEqual(a.config.Routing, cfg.Routing)
+	var (
+		n        fanoutNotifier
+		channels []configuredNotifier
+	)
+	if notifiersChanged {
+		if channels, err = buildChannels(cfg.Notifications, a.channels); err != nil {
+			return err
+		}
+		if n, err = buildNotifier(cfg, channels); err != nil {
+			return err
+		}
+	}
+
+	a.warnRestartNeeded(cfg)
+
+	if notifiersChanged {
+		instrumentNotifier(n, a.state, a.metrics, a.logger)
+		if a.outbox != nil {
+			n.SetOutbox(a.outbox)
+		}
+		a.checks.SetNotifier(n)
+		a.notifier = n
+		a.channels = channels
+		a.logger.Printf("config reload: notifying via %s", n.Name())
+	}
+
+	a.applyChecks(cfg.Checks, declared)
+
+	a.config = cfg
+	a.checkCount = len(all)
+	return nil
+}
+
+// applyChecks registers, unregisters and reschedules declared checks so
+// the scheduler matches configs. built holds the check for each config.
+func (a *app) applyChecks(configs []config.CheckConfig, built []check.Check) {
+	previous := make(map[string]config.CheckConfig, len(a.config.Checks))
+	for _, cc := range a.config.Checks {
+		previous[cc.Name] = cc
+	}
+
+	current := make(map[string]bool, len(configs))
+	for _, cc := range configs {
+		current[cc.Name] = true
+	}
+	for name := range previous {
+		if !current[name] {
+			a.unregister(name)
+			a.forget(name)
+			a.logger.Printf("config reload: removed check %s", name)
+		}
+	}
+
+	for i, cc := range configs {
+		old, existed := previous[cc.Name]
+		switch {
+		case !existed:
+			if a.register(built[i]) {
+				a.logger.Printf("config reload: added check %s", cc.Name)
+			}
+		case !reflect.DeepEqual(old, cc):
+			a.unregister(cc.Name)
+			if a.register(built[i]) {
+				a.logger.Printf("config reload: rescheduled check %s", cc.Name)
+			}
+		}
+	}
+}
+
+// register adds a check to the scheduler, logging failures
+func (a *app) register(c check.Check) bool {
+	if err := a.checks.Register(c); err != nil {
+		a.logger.Printf("config reload: %v", err)
+		return false
+	}
+	return true
+}
+
+// unregister removes a check from the scheduler, logging failures
+func (a *app) unregister(name string) {
+	if err := a.checks.Unregister(name); err != nil {
+		a.logger.Printf("config reload: %v", err)
+	}
+}
+
+// forget clears a removed check's open alert and drops its queued
+// notifications, so neither outlives the check. Rescheduled checks keep both.
+func (a *app) forget(name string) {
+	if err := a.state.Clear(name); err != nil {
+		a.logger.Printf("config reload: clear %s: %v", name, err)
+	}
+	if outbox, ok := a.state.(state.Outbox); ok {
+		if _, err := outbox.CancelDeliveries(name, ""); err != nil {
+			a.logger.Printf("config reload: drop queued notifications for %s: %v", name, err)
+		}
+	}
+}
+
+// warnRestartNeeded logs settings in cfg that differ from the running
+// config but only take effect on restart
+func (a *app) warnRestartNeeded(cfg *config.Config) {
+	sections := []struct {
+		name    string
+		changed bool
+	}{
+		{"state", !reflect.DeepEqual(a.config.State, cfg.State)},
+		{"claude", !reflect.DeepEqual(a.config.Claude, cfg.Claude)},
+		{"server", !reflect.DeepEqual(a.config.Server, cfg.Server)},
+		{"renotify", !reflect.DeepEqual(a.config.Renotify, cfg.Renotify)},
+		{"check_timeout", a.config.CheckTimeout != cfg.CheckTimeout},
+		{"notification_retry", a.config.Retry != cfg.Retry},
+	}
+	for _, section := range sections {
+		if section.changed {
+			a.logger.Printf("config reload: %s changed, restart to apply", section.name)
+		}
+	}
+}
+
+// reloadConfig reloads the config file, logging the outcome
+func (a *app) reloadConfig(configPath string) {
+	a.logger.Printf("reloading %s", configPath)
+	if err := a.reload(configPath); err != nil {
+		a.logger.Printf("config reload failed, keeping current config: %v", err)
+		return
+	}
+	a.logger.Printf("config reloaded, %d checks", a.checkCount)
+}
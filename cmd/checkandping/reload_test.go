@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/metrics"
+	"github.com/murr/check-and-ping/internal/notifier"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// fakeRegistry records what a reload asks of the scheduler, failing to
+// register the names in fail
+type fakeRegistry struct {
+	calls    []string
+	fail     map[string]bool
+	notifier notifier.Notifier
+}
+
+func (f *fakeRegistry) Register(c check.Check) error {
+	f.calls = append(f.calls, "register "+c.Name)
+	if f.fail[c.Name] {
+		return errors.New("register failed: " + c.Name)
+	}
+	return nil
+}
+
+func (f *fakeRegistry) Unregister(name string) error {
+	f.calls = append(f.calls, "unregister "+name)
+	return nil
+}
+
+func (f *fakeRegistry) SetNotifier(n notifier.Notifier) {
+	f.notifier = n
+}
+
+const baseConfig = `
+checks:
+  - name: api
+    url: https://api.example.com/health
+  - name: web
+    url: https://example.com
+    interval: 1m
+  - name: old
+    url: https://old.example.com
+`
+
+// newReloadApp loads config from a temp file into an app whose scheduler is
+// a fakeRegistry, and returns the app and the file's path
+func newReloadApp(t *testing.T, config string) (*app, *fakeRegistry, *state.Memory, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, config)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := state.NewMemory()
+	registry := &fakeRegistry{}
+	return &app{
+		config:  cfg,
+		checks:  registry,
+		state:   st,
+		pings:   st,
+		metrics: metrics.NewRegistry(),
+		logger:  log.New(io.Discard, "", 0),
+	}, registry, st, path
+}
+
+func writeConfig(t *testing.T, path, config string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadAppliesChecks(t *testing.T) {
+	a, registry, st, path := newReloadApp(t, baseConfig)
+	for _, name := range []string{"web", "old"} {
+		st.MarkAlerted(name, "h", "CRITICAL", "high")
+		st.Enqueue(state.Delivery{Notifier: "slack", CheckName: name})
+	}
+
+	writeConfig(t, path, `
+checks:
+  - name: api
+    url: https://api.example.com/health
+  - name: web
+    url: https://example.com
+    interval: 5m
+  - name: new
+    url: https://new.example.com
+`)
+	if err := a.reload(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"unregister old", "unregister web", "register web", "register new"}
+	if !slices.Equal(registry.calls, want) {
+		t.Errorf("calls = %v, want %v", registry.calls, want)
+	}
+	if registry.notifier != nil {
+		t.Error("notifier replaced though notifications didn't change")
+	}
+	if len(a.config.Checks) != 3 || a.config.Checks[2].Name != "new" {
+		t.Errorf("config checks = %+v, want the reloaded ones", a.config.Checks)
+	}
+
+	// The removed check's alert and queued notifications are gone; the
+	// rescheduled check keeps its own
+	if _, open := st.Incident("old"); open {
+		t.Error("removed check's incident is still open")
+	}
+	if _, open := st.Incident("web"); !open {
+		t.Error("rescheduled check's incident was cleared")
+	}
+	queued, _ := st.DueDeliveries(time.Now(), 0)
+	if len(queued) != 1 || queued[0].CheckName != "web" {
+		t.Errorf("queued deliveries = %+v, want only web's", queued)
+	}
+}
+
+func TestReloadUnchanged(t *testing.T) {
+	a, registry, _, path := newReloadApp(t, baseConfig)
+
+	if err := a.reload(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.calls) != 0 {
+		t.Errorf("calls = %v, want none", registry.calls)
+	}
+}
+
+func TestReloadRegisterFails(t *testing.T) {
+	a, registry, _, path := newReloadApp(t, baseConfig)
+	registry.fail = map[string]bool{"new": true}
+	var logs strings.Builder
+	a.logger = log.New(&logs, "", 0)
+
+	writeConfig(t, path, baseConfig+"  - name: new\n    url: https://new.example.com\n  - name: other\n    url: https://other.example.com\n")
+	if err := a.reload(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failure is logged and the remaining checks are still applied
+	want := []string{"register new", "register other"}
+	if !slices.Equal(registry.calls, want) {
+		t.Errorf("calls = %v, want %v", registry.calls, want)
+	}
+	if !strings.Contains(logs.String(), "register failed: new") || strings.Contains(logs.String(), "added check new") {
+		t.Errorf("logs = %q, want the failure logged instead of the addition", logs.String())
+	}
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"unparseable", "checks: [\n"},
+		{"invalid check", "checks:\n  - name: api\n    url: ftp://example.com\n"},
+		{"duplicate notifier", "notifications:\n  - type: stdout\n  - type: stdout\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, registry, st, path := newReloadApp(t, baseConfig)
+			st.MarkAlerted("old", "h", "CRITICAL", "high")
+			before := a.config
+
+			writeConfig(t, path, tt.config)
+			if err := a.reload(path); err == nil {
+				t.Fatal("reloaded an invalid config")
+			}
+			if len(registry.calls) != 0 || registry.notifier != nil {
+				t.Errorf("calls = %v, notifier = %v, want the scheduler untouched", registry.calls, registry.notifier)
+			}
+			if a.config != before {
+				t.Error("config replaced")
+			}
+			if _, open := st.Incident("old"); !open {
+				t.Error("state changed")
+			}
+		})
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, baseConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	go watchConfig(ctx, path, 10*time.Millisecond, changed)
+
+	select {
+	case <-changed:
+		t.Fatal("change reported for an unchanged file")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeConfig(t, path, baseConfig+"  - name: new\n    url: https://new.example.com\n")
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not reported")
+	}
+
+	// Removing the file isn't a change to reload
+	os.Remove(path)
+	select {
+	case <-changed:
+		t.Fatal("change reported for a removed file")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+const silenceUsage = `Usage:
+  checkandping ack [--config path] [--comment text] CHECK
+  checkandping snooze [--config path] [--comment text] CHECK DURATION
+  checkandping silence add [--config path] [--check glob] [--tag tag]... --for DURATION [--comment text]
+  checkandping silence list [--config path] [--all]
+  checkandping silence expire [--config path] ID
+
+ack stops reminders for a check's open alert until its status changes.
+snooze and silence mute all notifications for matching checks until they expire.
+All require sqlite state, shared with the running daemon.
+`
+
+// stringList is a repeatable flag; each value may also be comma-separated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// cmdSilence handles the ack, snooze and silence commands
+func cmdSilence(command string, args []string) int {
+	if command == "silence" {
+		if len(args) == 0 {
+			fmt.Fprint(os.Stderr, silenceUsage)
+			return 2
+		}
+		command, args = "silence "+args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, silenceUsage) }
+	configPath := fs.String("config", defaultConfigPath, "path to config file")
+	comment := fs.String("comment", "", "why the alert is acknowledged or muted")
+	checkGlob := fs.String("check", "", "glob on check names, e.g. \"court-*\"")
+	duration := fs.Duration("for", 0, "how long the silence lasts, e.g. 2h")
+	all := fs.Bool("all", false, "include expired silences")
+	var tags stringList
+	fs.Var(&tags, "tag", "alert tag to match (repeatable)")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	silences, closeState, err := openSilences(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer closeState()
+
+	switch command {
+	case "ack":
+		if len(args) != 1 {
+			fs.Usage()
+			return 2
+		}
+		if err := silences.Acknowledge(args[0], *comment); err != nil {
+			if errors.Is(err, state.ErrNoIncident) {
+				fmt.Fprintf(os.Stderr, "%s has no open alert to acknowledge\n", args[0])
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("acknowledged %s\n", args[0])
+		return 0
+
+	case "snooze":
+		if len(args) != 2 {
+			fs.Usage()
+			return 2
+		}
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "invalid duration: %s\n", args[1])
+			return 2
+		}
+		return addSilence(silences, state.Silence{
+			CheckGlob: escapeGlob(args[0]),
+			Comment:   *comment,
+			ExpiresAt: time.Now().Add(d),
+		})
+
+	case "silence add":
+		if len(args) != 0 {
+			fs.Usage()
+			return 2
+		}
+		if *duration <= 0 {
+			fmt.Fprintln(os.Stderr, "--for is required")
+			return 2
+		}
+		return addSilence(silences, state.Silence{
+			CheckGlob: *checkGlob,
+			Tags:      tags,
+			Comment:   *comment,
+			ExpiresAt: time.Now().Add(*duration),
+		})
+
+	case "silence list":
+		if len(args) != 0 {
+			fs.Usage()
+			return 2
+		}
+		list, err := silences.ListSilences(*all)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCHECK\tTAGS\tEXPIRES\tCOMMENT")
+		for _, s := range list {
+			expires := s.ExpiresAt.Local().Format(time.DateTime)
+			if !s.Active(time.Now()) {
+				expires += " (expired)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.ID, orAny(s.CheckGlob), orAny(strings.Join(s.Tags, ",")), expires, s.Comment)
+		}
+		w.Flush()
+		return 0
+
+	case "silence expire":
+		if len(args) != 1 {
+			fs.Usage()
+			return 2
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid silence id: %s\n", args[0])
+			return 2
+		}
+		if err := silences.ExpireSilence(id); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("expired silence %d\n", id)
+		return 0
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", command, silenceUsage)
+		return 2
+	}
+}
+
+// parseInterspersed parses flags that may come before, between or after
+// the positional arguments, e.g. "ack CHECK --comment text", and returns
+// the positional arguments. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// openSilences opens the configured state database for managing silences.
+// Pruning is left to the daemon.
+func openSilences(configPath string) (state.Silences, func(), error) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.State.Type != "sqlite" {
+		return nil, nil, fmt.Errorf("silences require sqlite state (state.type is %q)", cfg.State.Type)
+	}
+
+	st, err := state.NewSQLite(cfg.State.DBPath, state.WithoutPruning())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return st, func() { st.Close() }, nil
+}
+
+// addSilence stores a silence and prints its ID
+func addSilence(silences state.Silences, s state.Silence) int {
+	id, err := silences.AddSilence(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("silence %d active until %s\n", id, s.ExpiresAt.Local().Format(time.DateTime))
+	return 0
+}
+
+// escapeGlob quotes glob metacharacters so a check name matches only itself
+func escapeGlob(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// orAny shows an empty matcher as "*"
+func orAny(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		comment    string
+		all        bool
+	}{
+		{"flags first", []string{"--comment", "on it", "api"}, []string{"api"}, "on it", false},
+		{"flags after positional", []string{"api", "--comment", "on it"}, []string{"api"}, "on it", false},
+		{"flags between positional", []string{"api", "-comment=later", "2h", "--all"}, []string{"api", "2h"}, "later", true},
+		{"no flags", []string{"api", "2h"}, []string{"api", "2h"}, "", false},
+		{"double dash", []string{"--all", "--", "--comment", "api"}, []string{"--comment", "api"}, "", true},
+		{"double dash after positional", []string{"api", "--", "-x"}, []string{"api", "-x"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			comment := fs.String("comment", "", "")
+			all := fs.Bool("all", false, "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(positional, tt.positional) || *comment != tt.comment || *all != tt.all {
+				t.Errorf("parsed %q comment=%q all=%t, want %q comment=%q all=%t",
+					positional, *comment, *all, tt.positional, tt.comment, tt.all)
+			}
+		})
+	}
+}
+
+func TestParseInterspersedUnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterspersed(fs, []string{"api", "--nope"}); err == nil {
+		t.Error("parsed an unknown flag")
+	}
+}
+
+func TestCmdSilence(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "state.db")
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("state:\n  type: sqlite\n  db_path: "+dbPath+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The daemon's state, open alongside the CLI
+	st, err := state.NewSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	st.MarkAlerted("court-1", "h", "CRITICAL", "high")
+
+	quiet(t)
+	if code := cmdSilence("ack", []string{"court-1", "--config", configPath, "--comment", "on it"}); code != 0 {
+		t.Fatalf("ack exited %d", code)
+	}
+	if inc, _ := st.Incident("court-1"); !inc.Acknowledged() || inc.AckComment != "on it" {
+		t.Errorf("incident = %+v, want acknowledged", inc)
+	}
+	if code := cmdSilence("ack", []string{"--config", configPath, "api"}); code != 1 {
+		t.Errorf("ack without an alert exited %d, want 1", code)
+	}
+
+	if code := cmdSilence("snooze", []string{"court-[1]", "2h", "--config", configPath}); code != 0 {
+		t.Fatalf("snooze exited %d", code)
+	}
+	if code := cmdSilence("silence", []string{"add", "--config", configPath, "--tag", "db,web", "--for", "30m"}); code != 0 {
+		t.Fatalf("silence add exited %d", code)
+	}
+	if code := cmdSilence("silence", []string{"add", "--config", configPath, "--tag", "db"}); code != 2 {
+		t.Errorf("silence add without --for exited %d, want 2", code)
+	}
+
+	silences, _ := st.ListSilences(false)
+	if len(silences) != 2 {
+		t.Fatalf("%d active silences, want 2", len(silences))
+	}
+	if snooze := silences[1]; snooze.CheckGlob != `court-\[1\]` || time.Until(snooze.ExpiresAt) < time.Hour {
+		t.Errorf("snooze = %+v, want an escaped glob for 2h", snooze)
+	}
+	if _, ok := st.Silenced("court-1", nil, time.Now()); ok {
+		t.Error("snoozing court-[1] silenced court-1")
+	}
+
+	if code := cmdSilence("silence", []string{"expire", "--config", configPath, "1"}); code != 0 {
+		t.Fatalf("silence expire exited %d", code)
+	}
+	if silences, _ := st.ListSilences(false); len(silences) != 1 {
+		t.Errorf("%d active silences after expiring one, want 1", len(silences))
+	}
+}
+
+func TestCmdSilenceNeedsSQLite(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("state:\n  type: memory\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	quiet(t)
+	if code := cmdSilence("silence", []string{"list", "--config", configPath}); code != 1 {
+		t.Errorf("silence list with memory state exited %d, want 1", code)
+	}
+}
+
+// quiet discards what commands print for the rest of the test
+func quiet(t *testing.T) {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		devNull.Close()
+	})
+}
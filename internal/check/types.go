@@ -2,6 +2,8 @@ package check
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/murr/check-and-ping/internal/claude"
@@ -32,25 +34,185 @@ func (p Priority) String() string {
 	}
 }
 
+// ParsePriority converts a priority name ("low", "normal", "high", "urgent")
+// into a Priority. An empty string yields PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(s) {
+	case "low":
+		return PriorityLow, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	case "urgent":
+		return PriorityUrgent, nil
+	default:
+		return PriorityNormal, fmt.Errorf("unknown priority: %s", s)
+	}
+}
+
+// Status is the Nagios-style state of a check
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusWarning
+	StatusCritical
+	StatusUnknown // the check itself failed, so the real state is unknown
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "WARNING"
+	case StatusCritical:
+		return "CRITICAL"
+	case StatusUnknown:
+		return "UNKNOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ParseStatus converts a status name ("ok", "warning", "critical",
+// "unknown", in any case) into a Status
+func ParseStatus(s string) (Status, error) {
+	switch strings.ToUpper(s) {
+	case "OK":
+		return StatusOK, nil
+	case "WARNING", "WARN":
+		return StatusWarning, nil
+	case "CRITICAL", "CRIT":
+		return StatusCritical, nil
+	case "UNKNOWN":
+		return StatusUnknown, nil
+	default:
+		return StatusUnknown, fmt.Errorf("unknown status: %s", s)
+	}
+}
+
 // CheckResult represents the outcome of a check
 type CheckResult struct {
+	// Status is the check's state. Checks that only set ShouldAlert are
+	// treated as WARNING, or CRITICAL at high priority and above.
+	Status      Status
 	ShouldAlert bool
 	Title       string
 	Message     string
 	Priority    Priority
 	Tags        []string
 	Metadata    map[string]string
+
+	// RecoveryTitle and RecoveryMessage optionally override the "all clear"
+	// notification sent when a previously alerting check stops alerting.
+	// They are read from the result that clears the condition.
+	RecoveryTitle   string
+	RecoveryMessage string
+}
+
+// Level returns the result's status, deriving it from ShouldAlert and
+// Priority for checks that don't set Status
+func (r CheckResult) Level() Status {
+	if r.Status != StatusOK {
+		return r.Status
+	}
+	if !r.ShouldAlert {
+		return StatusOK
+	}
+	if r.Priority >= PriorityHigh {
+		return StatusCritical
+	}
+	return StatusWarning
+}
+
+// ErrorResult is the UNKNOWN result recorded for a check that returned an
+// error. It only alerts for checks with AlertOnError set.
+func ErrorResult(err error) CheckResult {
+	return CheckResult{
+		Status:   StatusUnknown,
+		Title:    "Check failed",
+		Message:  err.Error(),
+		Priority: PriorityNormal,
+	}
 }
 
 // CheckFunc is the signature for user-defined checks.
-// The claude parameter is optional - checks that don't need AI analysis can ignore it.
-type CheckFunc func(ctx context.Context, claude *claude.Client) (CheckResult, error)
+// The claude parameter is nil when Claude is disabled - checks that don't need AI analysis can ignore it.
+type CheckFunc func(ctx context.Context, claude claude.Analyzer) (CheckResult, error)
 
 // Check wraps a CheckFunc with scheduling metadata
 type Check struct {
 	Name     string
 	Interval time.Duration
-	Run      CheckFunc
+	// Schedule is an optional cron expression (see cron.Parse) that takes
+	// precedence over Interval, e.g. "CRON_TZ=America/Los_Angeles 0 9 * * MON-FRI"
+	Schedule string
+	// Timeout bounds a single run (the scheduler default applies if zero)
+	Timeout time.Duration
+	Run     CheckFunc
+	// DisableRecovery suppresses the "all clear" notification when an alert condition clears
+	DisableRecovery bool
+	// Renotify overrides the scheduler's default reminder policy when set
+	Renotify *RenotifyPolicy
+	// FailureThreshold is how many consecutive non-OK runs it takes to
+	// alert (default 1). SuccessThreshold is how many consecutive OK runs
+	// it takes to resolve an open alert (default 1).
+	FailureThreshold int
+	SuccessThreshold int
+	// Flap pauses notifications while the check keeps changing status
+	Flap FlapPolicy
+	// AlertOnError sends an UNKNOWN alert when the check returns an error
+	// (subject to FailureThreshold). Otherwise errors only back off.
+	AlertOnError bool
+	// Heartbeat marks a push check fed by pings (see Scheduler.Ping)
+	// instead of one that probes something itself
+	Heartbeat bool
+}
+
+// FlapPolicy detects a check bouncing between statuses. While flapping,
+// one "flapping" notification is sent instead of one per status change.
+type FlapPolicy struct {
+	Window     time.Duration // how far back status changes are counted
+	MaxChanges int           // changes within Window that mark the check as flapping (0 disables)
+}
+
+// Enabled reports whether flap detection is on
+func (p FlapPolicy) Enabled() bool {
+	return p.MaxChanges > 0 && p.Window > 0
+}
+
+// Flapping reports whether a check with changes status changes in the
+// window is flapping. A flapping check only settles once the changes drop
+// to half the threshold, so it doesn't toggle in and out of flapping.
+func (p FlapPolicy) Flapping(changes int, wasFlapping bool) bool {
+	if !p.Enabled() {
+		return false
+	}
+	if wasFlapping {
+		return changes > p.MaxChanges/2
+	}
+	return changes >= p.MaxChanges
+}
+
+// RenotifyPolicy repeats an alert while its condition stays unchanged
+type RenotifyPolicy struct {
+	Interval time.Duration // Time since the last notification before repeating (0 disables)
+	MaxCount int           // Maximum repeats per condition (0 means unlimited)
+	Escalate bool          // Raise priority one level per repeat, up to urgent
+}
+
+// Due reports whether an alert last sent at lastSent, and sent count times
+// so far, should be repeated at now
+func (p RenotifyPolicy) Due(lastSent time.Time, count int, now time.Time) bool {
+	if p.Interval <= 0 {
+		return false
+	}
+	if p.MaxCount > 0 && count-1 >= p.MaxCount {
+		return false
+	}
+	return now.Sub(lastSent) >= p.Interval
 }
 
 // Alert represents a notification to be sent
@@ -62,17 +224,129 @@ type Alert struct {
 	Tags      []string
 	Metadata  map[string]string
 	Timestamp time.Time
+	// Recovery is true for "all clear" notifications sent when a condition clears
+	Recovery bool
+	// Repeat counts reminders sent for an unchanged condition (0 for the first alert)
+	Repeat int
+	// Status is the check's new state and PreviousStatus the state it
+	// changed from (equal for reminders)
+	Status         Status
+	PreviousStatus Status
+	// Flapping is true for the notification sent when a check starts flapping
+	Flapping bool
+	// Escalation is how many levels Priority was raised for a reminder.
+	// Routes match on the priority before escalation (RoutePriority), so
+	// a reminder reaches the same notifiers as the alert it repeats.
+	Escalation int
+}
+
+// RoutePriority returns the priority routes match the alert on: its
+// priority without any reminder escalation
+func (a Alert) RoutePriority() Priority {
+	return a.Priority - Priority(a.Escalation)
+}
+
+// Escalate raises the alert's priority by levels, up to urgent
+func (a *Alert) Escalate(levels int) {
+	escalated := min(a.Priority+Priority(levels), PriorityUrgent)
+	a.Escalation += int(escalated - a.Priority)
+	a.Priority = escalated
+}
+
+// NewAlertFromResult creates an Alert for a check whose status changed from
+// previous to the result's status. The title is labeled with the new status;
+// the priority is the one the result declared.
+func NewAlertFromResult(checkName string, result CheckResult, previous Status) Alert {
+	status := result.Level()
+	return Alert{
+		CheckName:      checkName,
+		Title:          statusLabel(status) + result.Title,
+		Message:        result.Message,
+		Priority:       result.Priority,
+		Tags:           result.Tags,
+		Metadata:       withStatus(result.Metadata, status, previous),
+		Timestamp:      time.Now(),
+		Status:         status,
+		PreviousStatus: previous,
+	}
+}
+
+// statusLabel is the title prefix marking an alert's status
+func statusLabel(s Status) string {
+	return "[" + s.String() + "] "
+}
+
+// withStatus copies metadata and adds the status transition
+func withStatus(metadata map[string]string, status, previous Status) map[string]string {
+	out := make(map[string]string, len(metadata)+2)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out["status"] = status.String()
+	out["previous_status"] = previous.String()
+	return out
 }
 
-// NewAlertFromResult creates an Alert from a CheckResult
-func NewAlertFromResult(checkName string, result CheckResult) Alert {
+// NewRecoveryAlert creates an "all clear" Alert for a check that returned
+// to OK from previous after its condition lasted for duration. It is sent
+// at the priority the condition was alerted at, so it is routed like the
+// alert it resolves.
+func NewRecoveryAlert(checkName string, result CheckResult, previous Status, priority Priority, duration time.Duration) Alert {
+	lasted := duration.Round(time.Second).String()
+
+	title := result.RecoveryTitle
+	if title == "" {
+		title = "Resolved"
+	}
+	message := result.RecoveryMessage
+	if message == "" {
+		message = fmt.Sprintf("%s is no longer alerting (lasted %s)", checkName, lasted)
+	}
+
+	metadata := withStatus(result.Metadata, StatusOK, previous)
+	metadata["incident_duration"] = lasted
+
 	return Alert{
+		CheckName:      checkName,
+		Title:          statusLabel(StatusOK) + title,
+		Message:        message,
+		Priority:       priority,
+		Tags:           result.Tags,
+		Metadata:       metadata,
+		Timestamp:      time.Now(),
+		Recovery:       true,
+		Status:         StatusOK,
+		PreviousStatus: previous,
+	}
+}
+
+// NewFlapAlert creates the notification sent when a check starts flapping
+// (changes status changes within window) or settles again at the result's status
+func NewFlapAlert(checkName string, result CheckResult, started bool, changes int, window time.Duration) Alert {
+	status := result.Level()
+	alert := Alert{
 		CheckName: checkName,
-		Title:     result.Title,
-		Message:   result.Message,
 		Priority:  result.Priority,
 		Tags:      result.Tags,
-		Metadata:  result.Metadata,
+		Metadata:  withStatus(result.Metadata, status, status),
 		Timestamp: time.Now(),
+		Status:    status,
+		Flapping:  started,
+	}
+	alert.Metadata["status_changes"] = fmt.Sprint(changes)
+
+	if started {
+		alert.Title = "[FLAPPING] " + checkName + " is flapping"
+		alert.Message = fmt.Sprintf("%s changed status %d times in %s; notifications are paused until it settles (currently %s)",
+			checkName, changes, window, status)
+		return alert
+	}
+
+	alert.Title = statusLabel(status) + checkName + " stopped flapping"
+	alert.Message = fmt.Sprintf("%s has settled at %s", checkName, status)
+	if status != StatusOK && result.Title != "" {
+		alert.Message += ": " + result.Title
 	}
+	alert.Recovery = status == StatusOK
+	return alert
 }
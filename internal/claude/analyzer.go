@@ -0,0 +1,16 @@
+package claude
+
+import "context"
+
+// Analyzer is what checks use to ask Claude about content. *Client
+// implements it; tests can substitute claudetest.Fake.
+type Analyzer interface {
+	// Analyze answers prompt about content, which may be text or binary (PDF, images)
+	Analyze(ctx context.Context, prompt string, content []byte) (string, error)
+	// AnalyzeText answers prompt about text
+	AnalyzeText(ctx context.Context, prompt, text string) (string, error)
+	// AnalyzeFile answers prompt about the file at filePath
+	AnalyzeFile(ctx context.Context, prompt, filePath string) (string, error)
+}
+
+var _ Analyzer = (*Client)(nil)
@@ -0,0 +1,186 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAPIURL    = "https://api.anthropic.com"
+	defaultAPIModel  = "claude-sonnet-4-5"
+	defaultMaxTokens = 4096
+	apiVersion       = "2023-06-01"
+)
+
+// apiBackend calls the Anthropic Messages API directly
+type apiBackend struct {
+	key          string
+	baseURL      string
+	model        string
+	maxTokens    int
+	systemPrompt string
+	httpClient   *http.Client
+}
+
+func (b *apiBackend) id() string {
+	return "api\x00" + b.model + "\x00" + strconv.Itoa(b.maxTokens) + "\x00" + b.systemPrompt
+}
+
+func (b *apiBackend) name() string {
+	return "api"
+}
+
+// apiRequest is the body of a Messages API call
+type apiRequest struct {
+	Model     string       `json:"model"`
+	MaxTokens int          `json:"max_tokens"`
+	System    string       `json:"system,omitempty"`
+	Messages  []apiMessage `json:"messages"`
+}
+
+type apiMessage struct {
+	Role    string     `json:"role"`
+	Content []apiBlock `json:"content"`
+}
+
+// apiBlock is a text, image or document content block
+type apiBlock struct {
+	Type   string     `json:"type"`
+	Text   string     `json:"text,omitempty"`
+	Source *apiSource `json:"source,omitempty"`
+}
+
+type apiSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// apiResponse is the subset of a Messages API response we use
+type apiResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (b *apiBackend) complete(ctx context.Context, prompt, filePath string) (string, error) {
+	var blocks []apiBlock
+	if filePath != "" {
+		block, err := fileBlock(filePath)
+		if err != nil {
+			return "", err
+		}
+		blocks = append(blocks, block)
+	}
+	blocks = append(blocks, apiBlock{Type: "text", Text: prompt})
+
+	body, err := json.Marshal(apiRequest{
+		Model:     b.model,
+		MaxTokens: b.maxTokens,
+		System:    b.systemPrompt,
+		Messages:  []apiMessage{{Role: "user", Content: blocks}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(b.baseURL, "/")+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", b.key)
+	req.Header.Set("anthropic-version", apiVersion)
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	var res apiResponse
+	jsonErr := json.Unmarshal(respBody, &res)
+
+	if resp.StatusCode != http.StatusOK {
+		// Error bodies may not be JSON, e.g. from a proxy
+		msg := strings.TrimSpace(string(respBody))
+		if jsonErr == nil && res.Error != nil {
+			msg = res.Error.Type + ": " + res.Error.Message
+		}
+		// Rate limited (429) or overloaded (529): the caller should retry later
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 529 {
+			return "", fmt.Errorf("%w: claude API HTTP %d: %s", ErrRateLimited, resp.StatusCode, msg)
+		}
+		return "", fmt.Errorf("claude API error: HTTP %d: %s", resp.StatusCode, msg)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("decode response: %w", jsonErr)
+	}
+
+	var text strings.Builder
+	for _, block := range res.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("claude API returned no text (stop reason %q)", res.StopReason)
+	}
+
+	return strings.TrimSpace(text.String()), nil
+}
+
+// fileBlock reads a file into a document (PDF), image or text content block
+func fileBlock(filePath string) (apiBlock, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return apiBlock{}, fmt.Errorf("read file: %w", err)
+	}
+
+	var mediaType, blockType string
+	switch detectExtension(content) {
+	case ".pdf":
+		blockType, mediaType = "document", "application/pdf"
+	case ".png":
+		blockType, mediaType = "image", "image/png"
+	case ".jpg":
+		blockType, mediaType = "image", "image/jpeg"
+	case ".gif":
+		blockType, mediaType = "image", "image/gif"
+	case ".webp":
+		blockType, mediaType = "image", "image/webp"
+	default:
+		if isBinary(content) {
+			return apiBlock{}, fmt.Errorf("unsupported file type for the Claude API: %s", filePath)
+		}
+		return apiBlock{Type: "text", Text: string(content)}, nil
+	}
+
+	return apiBlock{
+		Type: blockType,
+		Source: &apiSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      base64.StdEncoding.EncodeToString(content),
+		},
+	}, nil
+}
@@ -0,0 +1,172 @@
+package claude
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// apiServer stubs the Messages API, recording the last request and
+// answering with status and body
+func apiServer(t *testing.T, status int, body string) (*httptest.Server, *http.Request, *apiRequest) {
+	t.Helper()
+	var (
+		got     http.Request
+		payload apiRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r.Clone(context.Background())
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, &payload
+}
+
+func newAPIClient(url string, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{
+		WithAPIKey("sk-test"),
+		WithAPIURL(url + "/"),
+		WithLogger(log.New(io.Discard, "", 0)),
+	}, opts...)
+	return NewClient(opts...)
+}
+
+func TestAPIRequest(t *testing.T) {
+	srv, req, payload := apiServer(t, http.StatusOK,
+		`{"content": [{"type": "text", "text": " Looks "}, {"type": "tool_use"}, {"type": "text", "text": "fine\n"}], "stop_reason": "end_turn"}`)
+	c := newAPIClient(srv.URL, WithModel("claude-test"), WithMaxTokens(512), WithSystemPrompt("Be brief."))
+
+	answer, err := c.AnalyzeText(context.Background(), "Is it up?", "status: ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Looks fine" {
+		t.Errorf("answer = %q, want the text blocks joined and trimmed", answer)
+	}
+
+	if req.Method != http.MethodPost || req.URL.Path != "/v1/messages" {
+		t.Errorf("request = %s %s, want POST /v1/messages", req.Method, req.URL.Path)
+	}
+	if req.Header.Get("x-api-key") != "sk-test" || req.Header.Get("anthropic-version") != apiVersion {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if payload.Model != "claude-test" || payload.MaxTokens != 512 || payload.System != "Be brief." {
+		t.Errorf("request = model %q, max_tokens %d, system %q", payload.Model, payload.MaxTokens, payload.System)
+	}
+	if len(payload.Messages) != 1 || payload.Messages[0].Role != "user" || len(payload.Messages[0].Content) != 1 {
+		t.Fatalf("messages = %+v, want one user message with one block", payload.Messages)
+	}
+	if block := payload.Messages[0].Content[0]; block.Type != "text" || block.Text != "status: ok\n\nIs it up?" {
+		t.Errorf("block = %+v", block)
+	}
+}
+
+func TestAPIRequestDefaults(t *testing.T) {
+	srv, _, payload := apiServer(t, http.StatusOK, `{"content": [{"type": "text", "text": "ok"}]}`)
+
+	if _, err := newAPIClient(srv.URL).AnalyzeText(context.Background(), "prompt", ""); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Model != defaultAPIModel || payload.MaxTokens != defaultMaxTokens || payload.System != "" {
+		t.Errorf("request = model %q, max_tokens %d, system %q", payload.Model, payload.MaxTokens, payload.System)
+	}
+}
+
+func TestAPIRequestPDF(t *testing.T) {
+	srv, _, payload := apiServer(t, http.StatusOK, `{"content": [{"type": "text", "text": "ok"}]}`)
+	pdf := []byte("%PDF-1.4\n\x00\x01binary")
+
+	if _, err := newAPIClient(srv.URL).Analyze(context.Background(), "Summarize", pdf); err != nil {
+		t.Fatal(err)
+	}
+	blocks := payload.Messages[0].Content
+	if len(blocks) != 2 || blocks[0].Type != "document" || blocks[1].Text != "Summarize" {
+		t.Fatalf("blocks = %+v, want a document then the prompt", blocks)
+	}
+	source := blocks[0].Source
+	if source == nil || source.Type != "base64" || source.MediaType != "application/pdf" ||
+		source.Data != base64.StdEncoding.EncodeToString(pdf) {
+		t.Errorf("source = %+v", source)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		rateLimited bool
+		want        string
+	}{
+		{
+			name:        "rate limited",
+			status:      http.StatusTooManyRequests,
+			body:        `{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`,
+			rateLimited: true,
+			want:        "HTTP 429: rate_limit_error: slow down",
+		},
+		{
+			name:        "overloaded",
+			status:      529,
+			body:        `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			rateLimited: true,
+			want:        "HTTP 529: overloaded_error: Overloaded",
+		},
+		{
+			name:        "overloaded without a JSON body",
+			status:      529,
+			body:        "<html>busy</html>",
+			rateLimited: true,
+			want:        "HTTP 529: <html>busy</html>",
+		},
+		{
+			name:   "invalid request",
+			status: http.StatusBadRequest,
+			body:   `{"type": "error", "error": {"type": "invalid_request_error", "message": "max_tokens: too large"}}`,
+			want:   "HTTP 400: invalid_request_error: max_tokens: too large",
+		},
+		{
+			name:   "server error",
+			status: http.StatusBadGateway,
+			body:   "bad gateway\n",
+			want:   "HTTP 502: bad gateway",
+		},
+		{
+			name:   "no text",
+			status: http.StatusOK,
+			body:   `{"content": [], "stop_reason": "max_tokens"}`,
+			want:   `no text (stop reason "max_tokens")`,
+		},
+		{
+			name:   "malformed response",
+			status: http.StatusOK,
+			body:   "not json",
+			want:   "decode response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := apiServer(t, tt.status, tt.body)
+
+			_, err := newAPIClient(srv.URL).AnalyzeText(context.Background(), "prompt", "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.want)
+			}
+			if errors.Is(err, ErrRateLimited) != tt.rateLimited {
+				t.Errorf("errors.Is(err, ErrRateLimited) = %t, want %t", !tt.rateLimited, tt.rateLimited)
+			}
+		})
+	}
+}
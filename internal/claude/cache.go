@@ -0,0 +1,111 @@
+package claude
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// maxMemoryCacheEntries bounds MemoryCache. When it is reached, expired
+// entries are swept, and if none had expired the one expiring soonest is evicted.
+const maxMemoryCacheEntries = 1000
+
+// Cache stores Claude responses keyed by a hash of the request
+type Cache interface {
+	// CacheGet returns an unexpired response for key
+	CacheGet(key string) (string, bool)
+	// CacheSet stores a response for key until ttl elapses
+	CacheSet(key, response string, ttl time.Duration) error
+}
+
+// CacheStats counts response cache lookups
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// cacheKey hashes everything that determines a response
+func cacheKey(backendID, prompt string, fileContent []byte) string {
+	h := sha256.New()
+	h.Write([]byte(backendID))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt))
+	h.Write([]byte{0})
+	h.Write(fileContent)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// memoryEntry is a cached response with its expiry
+type memoryEntry struct {
+	response  string
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process response cache
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryCache creates an empty in-memory response cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// CacheGet returns an unexpired response for key
+func (m *MemoryCache) CacheGet(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return "", false
+	}
+	return entry.response, true
+}
+
+// CacheSet stores a response for key until ttl elapses
+func (m *MemoryCache) CacheSet(key, response string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := m.entries[key]; !exists && len(m.entries) >= maxMemoryCacheEntries {
+		m.evict(now)
+	}
+
+	m.entries[key] = memoryEntry{
+		response:  response,
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// evict makes room for an entry by sweeping expired entries, or removing
+// the one expiring soonest if none have. The caller must hold m.mu.
+func (m *MemoryCache) evict(now time.Time) {
+	var (
+		soonest   string
+		soonestAt time.Time
+		found     bool
+	)
+	for k, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, k)
+			continue
+		}
+		if !found || e.expiresAt.Before(soonestAt) {
+			soonest, soonestAt, found = k, e.expiresAt, true
+		}
+	}
+	if len(m.entries) >= maxMemoryCacheEntries {
+		delete(m.entries, soonest)
+	}
+}
@@ -0,0 +1,62 @@
+package claude
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	c := NewMemoryCache()
+	c.CacheSet("fresh", "a", time.Hour)
+	c.CacheSet("stale", "b", -time.Second)
+
+	if got, ok := c.CacheGet("fresh"); !ok || got != "a" {
+		t.Errorf("CacheGet(fresh) = %q, %t", got, ok)
+	}
+	if _, ok := c.CacheGet("stale"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok := c.CacheGet("missing"); ok {
+		t.Error("missing entry returned")
+	}
+}
+
+func TestMemoryCacheBound(t *testing.T) {
+	c := NewMemoryCache()
+	// The first entry expires soonest, so it is the one evicted
+	for i := range maxMemoryCacheEntries {
+		c.CacheSet(fmt.Sprint(i), "r", time.Hour+time.Duration(i)*time.Second)
+	}
+
+	// Overwriting an entry doesn't evict another
+	c.CacheSet("1", "updated", 2*time.Hour)
+	if len(c.entries) != maxMemoryCacheEntries {
+		t.Fatalf("%d entries after overwriting, want %d", len(c.entries), maxMemoryCacheEntries)
+	}
+	if _, ok := c.CacheGet("0"); !ok {
+		t.Fatal("entry evicted by an overwrite")
+	}
+
+	c.CacheSet("new", "r", time.Hour)
+	if len(c.entries) != maxMemoryCacheEntries {
+		t.Errorf("%d entries, want the bound of %d", len(c.entries), maxMemoryCacheEntries)
+	}
+	if _, ok := c.CacheGet("0"); ok {
+		t.Error("entry expiring soonest was kept")
+	}
+	if _, ok := c.CacheGet("new"); !ok {
+		t.Error("new entry not stored")
+	}
+
+	// Expired entries go first, all at once
+	c.entries["2"] = memoryEntry{response: "r", expiresAt: time.Now().Add(-time.Second)}
+	c.entries["3"] = memoryEntry{response: "r", expiresAt: time.Now().Add(-time.Second)}
+	c.CacheSet("newer", "r", time.Hour)
+	if len(c.entries) != maxMemoryCacheEntries-1 {
+		t.Errorf("%d entries, want %d after sweeping two expired", len(c.entries), maxMemoryCacheEntries-1)
+	}
+	if _, ok := c.CacheGet("4"); !ok {
+		t.Error("unexpired entry evicted while expired ones were swept")
+	}
+}
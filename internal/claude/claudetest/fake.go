@@ -0,0 +1,125 @@
+// Package claudetest provides a scripted claude.Analyzer for testing checks
+// without the Claude CLI or API
+package claudetest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/murr/check-and-ping/internal/claude"
+)
+
+var _ claude.Analyzer = (*Fake)(nil)
+
+// Call records one request made to a Fake
+type Call struct {
+	Method   string // "Analyze", "AnalyzeText" or "AnalyzeFile"
+	Prompt   string
+	Content  []byte // content passed to Analyze, text passed to AnalyzeText, or the file's contents
+	FilePath string // AnalyzeFile only
+}
+
+// rule answers prompts matching pattern
+type rule struct {
+	pattern   *regexp.Regexp
+	responses []string
+	err       error
+	next      int
+}
+
+// Fake answers prompts with canned responses chosen by regex
+type Fake struct {
+	mu    sync.Mutex
+	rules []*rule
+	calls []Call
+}
+
+// NewFake creates a Fake with no responses; unmatched prompts fail
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// On answers prompts matching pattern with the given responses in order,
+// repeating the last one once they run out. Rules are tried in the order
+// they were added. It panics if pattern is not a valid regex.
+func (f *Fake) On(pattern string, responses ...string) *Fake {
+	if len(responses) == 0 {
+		panic("claudetest: On needs at least one response")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, &rule{pattern: regexp.MustCompile(pattern), responses: responses})
+	return f
+}
+
+// OnError fails prompts matching pattern with err
+func (f *Fake) OnError(pattern string, err error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, &rule{pattern: regexp.MustCompile(pattern), err: err})
+	return f
+}
+
+// Analyze records the call and returns the scripted answer for prompt
+func (f *Fake) Analyze(ctx context.Context, prompt string, content []byte) (string, error) {
+	return f.answer(ctx, Call{Method: "Analyze", Prompt: prompt, Content: content})
+}
+
+// AnalyzeText records the call and returns the scripted answer for prompt
+func (f *Fake) AnalyzeText(ctx context.Context, prompt, text string) (string, error) {
+	return f.answer(ctx, Call{Method: "AnalyzeText", Prompt: prompt, Content: []byte(text)})
+}
+
+// AnalyzeFile records the call and returns the scripted answer for prompt
+func (f *Fake) AnalyzeFile(ctx context.Context, prompt, filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	return f.answer(ctx, Call{Method: "AnalyzeFile", Prompt: prompt, Content: content, FilePath: filePath})
+}
+
+// Calls returns every call made so far, oldest first
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Reset forgets recorded calls and rewinds response sequences
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+	for _, r := range f.rules {
+		r.next = 0
+	}
+}
+
+// answer records a call and finds the first rule matching its prompt
+func (f *Fake) answer(ctx context.Context, call Call) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, call)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	for _, r := range f.rules {
+		if !r.pattern.MatchString(call.Prompt) {
+			continue
+		}
+		if r.err != nil {
+			return "", r.err
+		}
+		response := r.responses[min(r.next, len(r.responses)-1)]
+		r.next++
+		return response, nil
+	}
+
+	return "", fmt.Errorf("claudetest: no response scripted for prompt %q", call.Prompt)
+}
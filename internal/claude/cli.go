@@ -0,0 +1,77 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// backend sends a single request to Claude and returns the answer text
+type backend interface {
+	// id identifies the backend and everything that affects its answers,
+	// so cached responses aren't shared across models or backends
+	id() string
+	// name is the backend's metrics label, "cli" or "api"
+	name() string
+	// complete answers prompt, attaching the file at filePath if set
+	complete(ctx context.Context, prompt, filePath string) (string, error)
+}
+
+// cliBackend shells out to "claude -p"
+type cliBackend struct {
+	path  string
+	model string
+}
+
+func (b *cliBackend) id() string {
+	return "cli\x00" + b.model
+}
+
+func (b *cliBackend) name() string {
+	return "cli"
+}
+
+func (b *cliBackend) complete(ctx context.Context, prompt, filePath string) (string, error) {
+	args := []string{"-p", prompt, "--output-format", "json"}
+	if b.model != "" {
+		args = append(args, "--model", b.model)
+	}
+	if filePath != "" {
+		args = append(args, filePath)
+	}
+
+	cmd := exec.CommandContext(ctx, b.path, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("claude CLI error: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return parseCLIResult(stdout.String())
+}
+
+// cliResult is the envelope printed by "claude -p --output-format json"
+type cliResult struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	IsError bool   `json:"is_error"`
+	Result  string `json:"result"`
+}
+
+// parseCLIResult extracts the model's answer from the CLI's JSON output
+func parseCLIResult(output string) (string, error) {
+	var res cliResult
+	if err := json.Unmarshal([]byte(output), &res); err != nil {
+		return "", fmt.Errorf("parse claude CLI output: %w", err)
+	}
+	if res.IsError {
+		return "", fmt.Errorf("claude CLI error (%s): %s", res.Subtype, res.Result)
+	}
+	return strings.TrimSpace(res.Result), nil
+}
@@ -3,16 +3,41 @@ package claude
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"sync/atomic"
+	"time"
 )
 
-// Client wraps the Claude CLI for check analysis
+// Client sends check content to Claude for analysis, through the claude CLI
+// or directly to the Messages API
 type Client struct {
+	backend backend
 	cliPath string
+	model   string
+	logger  *log.Logger
+
+	// Messages API backend, used when apiKey is set
+	apiKey       string
+	apiURL       string
+	maxTokens    int
+	systemPrompt string
+	httpClient   *http.Client
+
+	limits  Limits
+	limiter *limiter
+
+	cache       Cache
+	cacheTTL    time.Duration
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+
+	metrics *clientMetrics
 }
 
 // ClientOption configures the Client
@@ -25,20 +50,113 @@ func WithCLIPath(path string) ClientOption {
 	}
 }
 
-// NewClient creates a new Claude CLI client
+// WithModel sets the model (the CLI's default, or claude-sonnet-4-5 for the API, if unset)
+func WithModel(model string) ClientOption {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithCache reuses responses for identical prompt, content and model for
+// up to ttl instead of invoking the CLI again
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+// WithAPIKey sends requests to the Messages API with this key instead of
+// running the CLI
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithAPIURL overrides the Messages API base URL (default https://api.anthropic.com)
+func WithAPIURL(url string) ClientOption {
+	return func(c *Client) {
+		c.apiURL = url
+	}
+}
+
+// WithMaxTokens limits the length of API answers (default 4096)
+func WithMaxTokens(n int) ClientOption {
+	return func(c *Client) {
+		c.maxTokens = n
+	}
+}
+
+// WithSystemPrompt sets the system prompt for API requests
+func WithSystemPrompt(prompt string) ClientOption {
+	return func(c *Client) {
+		c.systemPrompt = prompt
+	}
+}
+
+// WithHTTPClient sets the HTTP client for API requests
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithLimits bounds concurrent calls and calls per window. Calls that can't
+// get a slot in time fail with ErrRateLimited.
+func WithLimits(limits Limits) ClientOption {
+	return func(c *Client) {
+		c.limits = limits
+	}
+}
+
+// WithLogger sets the logger used to report queueing (default log.Default())
+func WithLogger(logger *log.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient creates a new Claude client. It uses the CLI unless an API key
+// is given with WithAPIKey.
 func NewClient(opts ...ClientOption) *Client {
 	c := &Client{
-		cliPath: "claude", // assume it's in PATH
+		cliPath:    "claude", // assume it's in PATH
+		apiURL:     defaultAPIURL,
+		maxTokens:  defaultMaxTokens,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		metrics:    newClientMetrics(nil),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.logger == nil {
+		c.logger = log.Default()
+	}
+	c.limiter = newLimiter(c.limits, c.logger)
+
+	if c.apiKey != "" {
+		model := c.model
+		if model == "" {
+			model = defaultAPIModel
+		}
+		c.backend = &apiBackend{
+			key:          c.apiKey,
+			baseURL:      c.apiURL,
+			model:        model,
+			maxTokens:    c.maxTokens,
+			systemPrompt: c.systemPrompt,
+			httpClient:   c.httpClient,
+		}
+	} else {
+		c.backend = &cliBackend{path: c.cliPath, model: c.model}
+	}
 
 	return c
 }
 
-// Analyze sends content to Claude CLI for analysis
+// Analyze sends content to Claude for analysis
 // For binary content (PDF, images), it writes to a temp file and passes the path
 func (c *Client) Analyze(ctx context.Context, prompt string, content []byte) (string, error) {
 	// Detect if content is binary (PDF, image, etc.)
@@ -47,12 +165,14 @@ func (c *Client) Analyze(ctx context.Context, prompt string, content []byte) (st
 	}
 
 	// For text content, just include it in the prompt
-	fullPrompt := string(content) + "\n\n" + prompt
-	return c.runClaude(ctx, fullPrompt, "")
+	return c.AnalyzeText(ctx, prompt, string(content))
 }
 
 // AnalyzeText sends text content for analysis
 func (c *Client) AnalyzeText(ctx context.Context, prompt, text string) (string, error) {
+	if text == "" {
+		return c.runClaude(ctx, prompt, "")
+	}
 	fullPrompt := text + "\n\n" + prompt
 	return c.runClaude(ctx, fullPrompt, "")
 }
@@ -64,52 +184,97 @@ func (c *Client) AnalyzeFile(ctx context.Context, prompt, filePath string) (stri
 
 // analyzeFile writes binary content to a temp file and analyzes it
 func (c *Client) analyzeFile(ctx context.Context, prompt string, content []byte) (string, error) {
+	tmpPath, cleanup, err := writeTempFile(content)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	return c.runClaude(ctx, prompt, tmpPath)
+}
+
+// writeTempFile writes binary content to a temp file named with a matching
+// extension, returning its path and a function that removes it
+func writeTempFile(content []byte) (string, func(), error) {
 	// Determine extension based on content
 	ext := detectExtension(content)
 
 	// Create temp file
 	tmpFile, err := os.CreateTemp("", "claude-check-*"+ext)
 	if err != nil {
-		return "", fmt.Errorf("create temp file: %w", err)
+		return "", nil, fmt.Errorf("create temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
+	cleanup := func() { os.Remove(tmpPath) }
 
 	// Write content
 	if _, err := tmpFile.Write(content); err != nil {
 		tmpFile.Close()
-		return "", fmt.Errorf("write temp file: %w", err)
+		cleanup()
+		return "", nil, fmt.Errorf("write temp file: %w", err)
 	}
 	tmpFile.Close()
 
-	return c.runClaude(ctx, prompt, tmpPath)
+	return tmpPath, cleanup, nil
 }
 
-// runClaude executes the claude CLI with the given prompt and optional file
+// runClaude sends the prompt and optional file to the configured backend,
+// answering from the cache when an identical request was made
 func (c *Client) runClaude(ctx context.Context, prompt string, filePath string) (string, error) {
-	args := []string{"-p", prompt}
-	if filePath != "" {
-		args = append(args, filePath)
+	var key string
+	if c.cache != nil {
+		var content []byte
+		if filePath != "" {
+			var err error
+			if content, err = os.ReadFile(filePath); err != nil {
+				return "", fmt.Errorf("read file: %w", err)
+			}
+		}
+		key = cacheKey(c.backend.id(), prompt, content)
+		if response, ok := c.cache.CacheGet(key); ok {
+			c.cacheHits.Add(1)
+			return response, nil
+		}
+		c.cacheMisses.Add(1)
 	}
 
-	cmd := exec.CommandContext(ctx, c.cliPath, args...)
+	if c.limiter != nil {
+		release, err := c.limiter.acquire(ctx)
+		if err != nil {
+			if errors.Is(err, ErrRateLimited) {
+				c.metrics.failures.Inc(c.backend.name(), "rate_limited")
+			}
+			return "", err
+		}
+		defer release()
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	start := time.Now()
+	response, err := c.backend.complete(ctx, prompt, filePath)
+	c.metrics.observeCall(c.backend.name(), time.Since(start), err)
+	if err != nil {
+		return "", err
+	}
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("claude CLI error: %w (stderr: %s)", err, stderr.String())
+	if c.cache != nil {
+		// A cache write failure only costs a future call
+		_ = c.cache.CacheSet(key, response, c.cacheTTL)
 	}
 
-	return strings.TrimSpace(stdout.String()), nil
+	return response, nil
 }
 
-// AnalyzeURL fetches a URL and analyzes it (convenience method)
-func (c *Client) AnalyzeURL(ctx context.Context, prompt, url string) (string, error) {
-	// For URLs, we can pass the URL directly and let claude fetch it,
-	// or we could fetch it ourselves. For now, assume caller fetches.
-	return "", fmt.Errorf("AnalyzeURL not implemented - fetch URL first and use Analyze()")
+// CacheEnabled reports whether responses are cached
+func (c *Client) CacheEnabled() bool {
+	return c.cache != nil
+}
+
+// CacheStats returns response cache hit and miss counts
+func (c *Client) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:   c.cacheHits.Load(),
+		Misses: c.cacheMisses.Load(),
+	}
 }
 
 // isBinary checks if content appears to be binary (not text)
@@ -0,0 +1,181 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultFetchMaxBytes     = 20 << 20 // 20 MiB
+	defaultFetchMaxRedirects = 10
+	defaultFetchTimeout      = 30 * time.Second
+)
+
+// FetchInfo describes a fetched URL, e.g. for alert metadata
+type FetchInfo struct {
+	URL         string // final URL after redirects
+	StatusCode  int
+	ContentType string // media type from the header, or sniffed if missing
+	Bytes       int    // body size as downloaded, before HTML is stripped
+}
+
+// fetchOptions configures FetchURL and AnalyzeURL
+type fetchOptions struct {
+	headers      http.Header
+	maxBytes     int
+	maxRedirects int
+	timeout      time.Duration
+}
+
+// FetchOption configures how a URL is fetched
+type FetchOption func(*fetchOptions)
+
+// WithFetchHeader adds a request header, e.g. for auth or a custom User-Agent
+func WithFetchHeader(key, value string) FetchOption {
+	return func(o *fetchOptions) {
+		o.headers.Add(key, value)
+	}
+}
+
+// WithFetchMaxBytes fails the fetch if the body is larger than n bytes (default 20 MiB)
+func WithFetchMaxBytes(n int) FetchOption {
+	return func(o *fetchOptions) {
+		if n > 0 {
+			o.maxBytes = n
+		}
+	}
+}
+
+// WithFetchMaxRedirects limits how many redirects are followed (default 10, 0 = none)
+func WithFetchMaxRedirects(n int) FetchOption {
+	return func(o *fetchOptions) {
+		o.maxRedirects = max(n, 0)
+	}
+}
+
+// WithFetchTimeout bounds the whole fetch (default 30s)
+func WithFetchTimeout(d time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// FetchURL downloads a URL and prepares it for analysis: binary content
+// (PDF, images) is returned as is and HTML is reduced to readable text.
+// Non-2xx responses are errors; the returned FetchInfo is filled in as far
+// as the fetch got.
+func FetchURL(ctx context.Context, url string, opts ...FetchOption) ([]byte, FetchInfo, error) {
+	o := fetchOptions{
+		headers:      make(http.Header),
+		maxBytes:     defaultFetchMaxBytes,
+		maxRedirects: defaultFetchMaxRedirects,
+		timeout:      defaultFetchTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	info := FetchInfo{URL: url}
+
+	client := &http.Client{
+		Timeout: o.timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > o.maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", o.maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, info, fmt.Errorf("create request: %w", err)
+	}
+	for key, values := range o.headers {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, info, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	info.URL = resp.Request.URL.String()
+	info.StatusCode = resp.StatusCode
+
+	// Read one byte past the limit to tell "exactly at the limit" from "over it"
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(o.maxBytes)+1))
+	info.Bytes = len(body)
+	if err != nil {
+		return nil, info, fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > o.maxBytes {
+		return nil, info, fmt.Errorf("fetch %s: body exceeds %d bytes", url, o.maxBytes)
+	}
+
+	info.ContentType = mediaType(resp.Header.Get("Content-Type"), body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, info, fmt.Errorf("fetch %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	if info.ContentType == "text/html" && !isBinary(body) {
+		body = []byte(htmlToText(string(body)))
+	}
+
+	return body, info, nil
+}
+
+// mediaType returns the media type from a Content-Type header, falling back
+// to sniffing the content when the header is missing or generic
+func mediaType(header string, body []byte) string {
+	if mt, _, err := mime.ParseMediaType(header); err == nil && mt != "application/octet-stream" {
+		return mt
+	}
+
+	switch detectExtension(body) {
+	case ".pdf":
+		return "application/pdf"
+	case ".png":
+		return "image/png"
+	case ".jpg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(body))
+	return mt
+}
+
+// AnalyzeURL fetches a URL and analyzes its content with the prompt. HTML
+// pages are reduced to text first; PDFs and images are passed as files.
+// The FetchInfo reports the final URL, status, content type and size.
+func (c *Client) AnalyzeURL(ctx context.Context, prompt, url string, opts ...FetchOption) (string, FetchInfo, error) {
+	content, info, err := FetchURL(ctx, url, opts...)
+	if err != nil {
+		return "", info, err
+	}
+
+	answer, err := c.Analyze(ctx, prompt, content)
+	return answer, info, err
+}
+
+// Metadata returns the fetch details as alert metadata
+func (i FetchInfo) Metadata() map[string]string {
+	return map[string]string{
+		"url":          i.URL,
+		"status":       fmt.Sprint(i.StatusCode),
+		"content_type": i.ContentType,
+		"bytes":        fmt.Sprint(i.Bytes),
+	}
+}
@@ -0,0 +1,77 @@
+package claude
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlHidden matches elements whose content isn't readable text, one
+	// pattern per element since RE2 can't match the closing tag by backreference
+	htmlHidden = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)<!--.*?-->`),
+		hiddenElement("script"),
+		hiddenElement("style"),
+		hiddenElement("noscript"),
+		hiddenElement("template"),
+		hiddenElement("svg"),
+		hiddenElement("head"),
+	}
+	// htmlBreak matches tags that start a new line of text
+	htmlBreak = regexp.MustCompile(`(?i)<(br|/?p|/?div|/?li|/?tr|/?h[1-6]|/?table|/?section|/?article|/?header|/?footer|/?blockquote|/?pre|hr)\b[^>]*>`)
+	// htmlCell matches table cell boundaries
+	htmlCell = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+	// htmlTitle captures the page title before <head> is dropped
+	htmlTitle = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+)
+
+// hiddenElement matches a whole element with the given tag name
+func hiddenElement(tag string) *regexp.Regexp {
+	return regexp.MustCompile(`(?is)<` + tag + `\b.*?</` + tag + `\s*>`)
+}
+
+// htmlToText reduces an HTML page to its readable text, keeping the title,
+// line breaks between blocks and "|"-separated table cells
+func htmlToText(page string) string {
+	var title string
+	if m := htmlTitle.FindStringSubmatch(page); m != nil {
+		title = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(m[1], "")))
+	}
+
+	text := page
+	for _, re := range htmlHidden {
+		text = re.ReplaceAllString(text, "")
+	}
+	text = htmlBreak.ReplaceAllString(text, "\n")
+	text = htmlCell.ReplaceAllString(text, " | ")
+	text = htmlTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = trimLines(text)
+
+	if title != "" && !strings.HasPrefix(text, title) {
+		text = title + "\n\n" + text
+	}
+	return text
+}
+
+// trimLines trims each line and drops runs of blank lines
+func trimLines(text string) string {
+	var b strings.Builder
+	blank := true
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank {
+				b.WriteString("\n")
+			}
+			blank = true
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		blank = false
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,59 @@
+package claude
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "title and blocks",
+			page: `<html><head><title>Status &amp; Health</title><meta charset="utf-8"></head>
+				<body><h1>All good</h1><p>Uptime: 99.9%</p><div>Last check<br>5 minutes ago</div></body></html>`,
+			want: "Status & Health\n\nAll good\n\nUptime: 99.9%\n\nLast check\n5 minutes ago",
+		},
+		{
+			name: "hidden elements",
+			page: `<body><script type="text/javascript">var x = "</style>";</script>
+				<style>p { color: red }</style><noscript>Enable JavaScript</noscript>
+				<template><p>row</p></template><svg><text>icon</text></svg>
+				<!-- <p>commented out</p> --><p>Visible</p></body>`,
+			want: "Visible",
+		},
+		{
+			name: "mixed case and spacing",
+			page: `<SCRIPT>alert(1)</SCRIPT ><Style>b{}</STYLE><P>Text</P>`,
+			want: "Text",
+		},
+		{
+			name: "header is not head",
+			page: `<header>Site</header><p>Body</p>`,
+			want: "Site\n\nBody",
+		},
+		{
+			name: "table cells",
+			page: `<table><tr><th>Case</th><th>Status</th></tr><tr><td>123</td><td>Open</td></tr></table>`,
+			want: "Case | Status |\n\n123 | Open |",
+		},
+		{
+			name: "entities and whitespace",
+			page: "<p>  a   &lt;b&gt;\t c  </p>\n\n\n<p>d&nbsp;e</p>",
+			want: "a <b> c\n\nd e",
+		},
+		{
+			name: "title already leads",
+			page: `<head><title>Report</title></head><h1>Report</h1><p>Body</p>`,
+			want: "Report\n\nBody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.page); got != tt.want {
+				t.Errorf("htmlToText =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
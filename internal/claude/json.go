@@ -0,0 +1,172 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const defaultJSONAttempts = 3
+
+// ErrMalformedJSON is returned when Claude fails to produce a valid JSON
+// answer within the allowed number of attempts
+var ErrMalformedJSON = errors.New("claude returned malformed JSON")
+
+// jsonOptions configures AnalyzeJSON
+type jsonOptions struct {
+	schema      *Schema
+	maxAttempts int
+}
+
+// JSONOption configures a structured analysis
+type JSONOption func(*jsonOptions)
+
+// WithSchema validates answers against an explicit JSON Schema instead of
+// one derived from the output type
+func WithSchema(schema *Schema) JSONOption {
+	return func(o *jsonOptions) {
+		o.schema = schema
+	}
+}
+
+// WithMaxAttempts sets how many times Claude is asked before giving up on a
+// malformed answer (default 3)
+func WithMaxAttempts(n int) JSONOption {
+	return func(o *jsonOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// AnalyzeJSON asks Claude to answer with JSON and decodes the answer into
+// out, which must be a pointer. See the package-level AnalyzeJSON.
+func (c *Client) AnalyzeJSON(ctx context.Context, prompt string, content []byte, out any, opts ...JSONOption) error {
+	return AnalyzeJSON(ctx, c, prompt, content, out, opts...)
+}
+
+// AnalyzeJSON asks an Analyzer to answer with JSON and decodes the answer
+// into out, which must be a pointer. The answer is validated against a
+// schema derived from out's type (or one given with WithSchema). Malformed
+// or invalid answers are re-prompted with the validation error; after the
+// last attempt an error wrapping ErrMalformedJSON is returned.
+//
+// content may be nil, text, or binary (PDF, images), as with Analyze.
+func AnalyzeJSON(ctx context.Context, a Analyzer, prompt string, content []byte, out any, opts ...JSONOption) error {
+	o := jsonOptions{maxAttempts: defaultJSONAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.schema == nil {
+		o.schema = SchemaFor(out)
+	}
+
+	schemaJSON, err := json.MarshalIndent(o.schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal schema: %w", err)
+	}
+
+	instructions := prompt + "\n\nRespond with only a JSON value that matches this JSON Schema. " +
+		"Do not include any other text or code fences.\n" + string(schemaJSON)
+
+	fullPrompt := instructions
+	for attempt := 1; ; attempt++ {
+		answer, err := a.Analyze(ctx, fullPrompt, content)
+		if err != nil {
+			return err
+		}
+
+		decodeErr := decodeAnswer(answer, o.schema, out)
+		if decodeErr == nil {
+			return nil
+		}
+		if attempt >= o.maxAttempts {
+			return fmt.Errorf("%w after %d attempts: %v", ErrMalformedJSON, attempt, decodeErr)
+		}
+
+		fullPrompt = instructions +
+			"\n\nYour previous answer was rejected: " + decodeErr.Error() +
+			"\nPrevious answer:\n" + answer +
+			"\n\nRespond again with only the corrected JSON."
+	}
+}
+
+// decodeAnswer extracts JSON from an answer, validates it and decodes it into out
+func decodeAnswer(answer string, schema *Schema, out any) error {
+	raw, ok := extractJSON(answer)
+	if !ok {
+		return errors.New("no JSON value found in answer")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := schema.Validate(generic); err != nil {
+		return err
+	}
+
+	// The schema accepts 1.0 as an integer but encoding/json doesn't, so
+	// decode a copy with integral numbers written as integers
+	raw, err := json.Marshal(integralNumbers(generic))
+	if err != nil {
+		return fmt.Errorf("decode answer: %w", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode answer: %w", err)
+	}
+	return nil
+}
+
+// integralNumbers rewrites numbers without a fractional part, such as 1.0
+// or 1e3, in integer form so they decode into integer fields
+func integralNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v
+		}
+		f, err := v.Float64()
+		if err != nil || f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+			return v
+		}
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	case map[string]any:
+		for k, e := range v {
+			v[k] = integralNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = integralNumbers(e)
+		}
+	}
+	return v
+}
+
+// extractJSON finds the first JSON object or array in text, tolerating
+// preambles, trailing commentary and markdown code fences
+func extractJSON(text string) ([]byte, bool) {
+	text = strings.TrimSpace(text)
+	if json.Valid([]byte(text)) {
+		return []byte(text), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&raw); err == nil {
+			return raw, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,75 @@
+package claude
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAnswer(t *testing.T) {
+	type answer struct {
+		Count  int       `json:"count"`
+		Price  float64   `json:"price"`
+		Status string    `json:"status"`
+		Sizes  []int64   `json:"sizes,omitempty"`
+		Scores []float64 `json:"scores,omitempty"`
+	}
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    answer
+		wantErr string
+	}{
+		{
+			name:   "plain",
+			answer: `{"count": 3, "price": 1.5, "status": "open"}`,
+			want:   answer{Count: 3, Price: 1.5, Status: "open"},
+		},
+		{
+			name:   "integral floats",
+			answer: `{"count": 3.0, "price": 2.0, "status": "open", "sizes": [1e3, -2.0], "scores": [4.0]}`,
+			want:   answer{Count: 3, Price: 2, Status: "open", Sizes: []int64{1000, -2}, Scores: []float64{4}},
+		},
+		{
+			name:   "code fence and commentary",
+			answer: "Here you go:\n```json\n{\"count\": 1, \"price\": 0, \"status\": \"closed\"}\n```\nLet me know!",
+			want:   answer{Count: 1, Status: "closed"},
+		},
+		{
+			name:    "fractional integer",
+			answer:  `{"count": 1.5, "price": 1, "status": "open"}`,
+			wantErr: "count",
+		},
+		{
+			name:    "missing field",
+			answer:  `{"count": 1, "price": 1}`,
+			wantErr: "status",
+		},
+		{
+			name:    "no JSON",
+			answer:  "I can't tell",
+			wantErr: "no JSON value",
+		},
+	}
+
+	schema := SchemaFor(&answer{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got answer
+			err := decodeAnswer(tt.answer, schema, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeAnswer error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeAnswer: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deadlineMargin is the most time left before a caller's deadline when a
+// queued call gives up, so it can still report ErrRateLimited
+const deadlineMargin = time.Second
+
+// ErrRateLimited is returned when a call could not get a slot within the
+// configured wait. It means "retry later", not that the analysis failed.
+var ErrRateLimited = errors.New("claude rate limit exceeded, retry later")
+
+// Limits bounds how many Claude calls run at once and how often they start
+type Limits struct {
+	MaxConcurrent int           // calls running at once (0 = unlimited)
+	MaxRequests   int           // calls started per Window (0 = unlimited)
+	Window        time.Duration // rate window (default 1m)
+	MaxWait       time.Duration // longest a call may queue before ErrRateLimited (0 = until its context's deadline)
+}
+
+// limiter enforces Limits across all callers of a Client
+type limiter struct {
+	limits Limits
+	logger *log.Logger
+
+	sem     chan struct{}
+	waiting atomic.Int64
+
+	mu     sync.Mutex
+	starts []time.Time // start times of calls within the current window
+}
+
+// newLimiter creates a limiter, or returns nil if limits are all unlimited
+func newLimiter(limits Limits, logger *log.Logger) *limiter {
+	if limits.MaxConcurrent <= 0 && limits.MaxRequests <= 0 {
+		return nil
+	}
+	if limits.Window <= 0 {
+		limits.Window = time.Minute
+	}
+
+	l := &limiter{limits: limits, logger: logger}
+	if limits.MaxConcurrent > 0 {
+		l.sem = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return l
+}
+
+// acquire waits for a concurrency slot and a rate slot, returning a function
+// that releases the concurrency slot. It fails with ErrRateLimited if no slot
+// frees up within MaxWait, or shortly before the context's deadline, so the
+// caller sees ErrRateLimited rather than its own timeout. It fails with the
+// context's error if ctx is canceled first.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	queued := l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	giveUp, bounded := giveUpTime(ctx, start, l.limits.MaxWait)
+	var deadline <-chan time.Time
+	if bounded {
+		timer := time.NewTimer(giveUp.Sub(start))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	release := func() {}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, l.limitedError(start, queued)
+		}
+		release = func() { <-l.sem }
+	}
+
+	for {
+		wait := l.reserve(time.Now())
+		if wait == 0 {
+			break
+		}
+		if bounded && time.Now().Add(wait).After(giveUp) {
+			// The next rate slot opens after our deadline; fail now instead of sleeping
+			release()
+			return nil, l.limitedError(start, queued)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	if waited := time.Since(start); waited >= time.Second {
+		l.logger.Printf("claude: waited %s for a slot (queue depth %d)", waited.Round(time.Millisecond), queued)
+	}
+
+	return release, nil
+}
+
+// giveUpTime returns when a call that started waiting at start stops
+// waiting for a slot: after maxWait, or a margin before ctx's deadline,
+// whichever comes first. The bool is false if neither bounds the wait.
+func giveUpTime(ctx context.Context, start time.Time, maxWait time.Duration) (time.Time, bool) {
+	giveUp, bounded := ctx.Deadline()
+	if bounded {
+		margin := giveUp.Sub(start) / 10
+		if margin > deadlineMargin {
+			margin = deadlineMargin
+		}
+		giveUp = giveUp.Add(-margin)
+	}
+	if maxWait > 0 && (!bounded || start.Add(maxWait).Before(giveUp)) {
+		giveUp, bounded = start.Add(maxWait), true
+	}
+	return giveUp, bounded
+}
+
+// reserve records a call start if the rate window has room, returning 0, or
+// returns how long until the window has room
+func (l *limiter) reserve(now time.Time) time.Duration {
+	if l.limits.MaxRequests <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.limits.Window)
+	i := 0
+	for i < len(l.starts) && !l.starts[i].After(cutoff) {
+		i++
+	}
+	l.starts = l.starts[i:]
+
+	if len(l.starts) < l.limits.MaxRequests {
+		l.starts = append(l.starts, now)
+		return 0
+	}
+	return l.starts[0].Add(l.limits.Window).Sub(now)
+}
+
+// limitedError logs and returns an ErrRateLimited for a call that gave up
+func (l *limiter) limitedError(start time.Time, queued int64) error {
+	waited := time.Since(start).Round(time.Millisecond)
+	l.logger.Printf("claude: gave up after %s waiting for a slot (queue depth %d)", waited, queued)
+	return fmt.Errorf("%w (waited %s, queue depth %d)", ErrRateLimited, waited, queued)
+}
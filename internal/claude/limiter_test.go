@@ -0,0 +1,77 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestLimiterRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxWait time.Duration
+		timeout time.Duration
+	}{
+		{"max wait", 50 * time.Millisecond, time.Minute},
+		{"context deadline", 0, 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(Limits{MaxRequests: 1, Window: time.Hour, MaxWait: tt.maxWait}, log.New(io.Discard, "", 0))
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			release, err := l.acquire(ctx)
+			if err != nil {
+				t.Fatalf("first acquire: %v", err)
+			}
+			release()
+
+			// The next slot opens in an hour, so give up without waiting
+			start := time.Now()
+			if _, err := l.acquire(ctx); !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("second acquire = %v, want ErrRateLimited", err)
+			}
+			if waited := time.Since(start); waited > 40*time.Millisecond {
+				t.Errorf("waited %s before giving up", waited)
+			}
+		})
+	}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	l := newLimiter(Limits{MaxConcurrent: 1, MaxWait: 20 * time.Millisecond}, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	release, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("acquire while busy = %v, want ErrRateLimited", err)
+	}
+
+	release()
+	release, err = l.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	l.acquire(ctx) // hold the slot
+	if _, err := l.acquire(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire with canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewLimiterUnlimited(t *testing.T) {
+	if l := newLimiter(Limits{}, nil); l != nil {
+		t.Errorf("newLimiter(Limits{}) = %+v, want nil", l)
+	}
+}
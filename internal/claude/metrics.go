@@ -0,0 +1,45 @@
+package claude
+
+import (
+	"time"
+
+	"github.com/murr/check-and-ping/internal/metrics"
+)
+
+// callDurationBuckets cover quick API answers through long CLI runs on documents
+var callDurationBuckets = []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// clientMetrics are the client's instruments; with a nil registry they
+// record nothing
+type clientMetrics struct {
+	calls    *metrics.Counter
+	failures *metrics.Counter
+	duration *metrics.Histogram
+}
+
+func newClientMetrics(reg *metrics.Registry) *clientMetrics {
+	return &clientMetrics{
+		calls: reg.Counter("checkandping_claude_calls_total",
+			"Claude invocations that reached the backend (cache hits are not counted).", "backend"),
+		failures: reg.Counter("checkandping_claude_failures_total",
+			"Claude invocations that failed, by reason (error or rate_limited).", "backend", "reason"),
+		duration: reg.Histogram("checkandping_claude_call_duration_seconds",
+			"How long Claude invocations took, excluding time queued by the limiter.", callDurationBuckets, "backend"),
+	}
+}
+
+// WithMetrics records Claude invocations, their latency and failures in reg
+func WithMetrics(reg *metrics.Registry) ClientOption {
+	return func(c *Client) {
+		c.metrics = newClientMetrics(reg)
+	}
+}
+
+// observeCall records a finished backend call
+func (m *clientMetrics) observeCall(backend string, d time.Duration, err error) {
+	m.calls.Inc(backend)
+	m.duration.Observe(d.Seconds(), backend)
+	if err != nil {
+		m.failures.Inc(backend, "error")
+	}
+}
@@ -0,0 +1,326 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used to describe and validate
+// structured answers: type, properties, required, additionalProperties,
+// items, enum and description
+type Schema struct {
+	Type                 any                `json:"type,omitempty"` // string or []string
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // bool or *Schema
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+}
+
+// ParseSchema parses a JSON Schema document
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &s, nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// SchemaFor derives a schema from a Go value's type. Exported struct fields
+// use their json tag names; fields without omitempty are required, except
+// pointers, which may also be null. Embedded structs are flattened as
+// encoding/json does, []byte is a base64 string, and a self-referential
+// type is described as a plain object where it recurses. A
+// `description:"..."` struct tag is copied into the schema to guide the model.
+func SchemaFor(v any) *Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return &Schema{}
+	}
+	return schemaForType(t, make(map[reflect.Type]bool))
+}
+
+// schemaForType derives the schema for t. visiting holds the struct types
+// being derived further up, to stop at recursive types.
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Description: "RFC 3339 timestamp"}
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return &Schema{Type: "string", Description: "base64-encoded bytes"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaForType(t.Elem(), visiting))
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(s, t, true, make(map[string]bool), visiting)
+		return s
+	default:
+		// interface{} and anything else: accept any value
+		return &Schema{}
+	}
+}
+
+// addFields adds the JSON fields of struct type t to s. Fields of embedded
+// structs are promoted unless a field named the same is declared directly,
+// and are only required if the embedded struct is always present.
+// declared holds the names of the fields already declared directly.
+func addFields(s *Schema, t reflect.Type, present bool, declared map[string]bool, visiting map[reflect.Type]bool) {
+	// Direct fields first, so they take precedence over promoted ones
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && !hasJSONName(f) {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+				continue
+			}
+		}
+		if !f.IsExported() || declared[name] {
+			continue
+		}
+		declared[name] = true
+
+		prop := schemaForType(f.Type, visiting)
+		if desc := f.Tag.Get("description"); desc != "" {
+			prop.Description = desc
+		}
+		s.Properties[name] = prop
+		if present && !omitempty && f.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if visiting[ft] {
+			continue
+		}
+		visiting[ft] = true
+		addFields(s, ft, present && f.Type.Kind() != reflect.Pointer, declared, visiting)
+		delete(visiting, ft)
+	}
+}
+
+// hasJSONName reports whether a field's json tag names it
+func hasJSONName(f reflect.StructField) bool {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name != ""
+}
+
+// nullable allows null in addition to the schema's type
+func nullable(s *Schema) *Schema {
+	if t, ok := s.Type.(string); ok {
+		s.Type = []string{t, "null"}
+	}
+	return s
+}
+
+// jsonFieldName returns the JSON name of a struct field as encoding/json would
+func jsonFieldName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, slices.Contains(strings.Split(opts, ","), "omitempty"), false
+}
+
+// Validate checks a decoded JSON value (as produced by a json.Decoder with
+// UseNumber) against the schema
+func (s *Schema) Validate(v any) error {
+	return s.validate(v, "$")
+}
+
+func (s *Schema) validate(v any, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	if types := s.types(); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return matchesType(v, t) }) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeOf(v))
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, v) }) {
+		return fmt.Errorf("%s: value %v is not one of %v", path, v, s.Enum)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				if err := prop.validate(v[k], path+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+			case *Schema:
+				if err := ap.validate(v[k], path+"."+k); err != nil {
+					return err
+				}
+			case map[string]any:
+				// Schemas parsed from JSON decode additionalProperties generically
+				sub, err := schemaFromMap(ap)
+				if err != nil {
+					return err
+				}
+				if err := sub.validate(v[k], path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// types returns the schema's allowed types
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				types = append(types, str)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// schemaFromMap converts a generically decoded schema into a Schema
+func schemaFromMap(m map[string]any) (*Schema, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchema(data)
+}
+
+// matchesType reports whether v is an instance of a JSON Schema type
+func matchesType(v any, t string) bool {
+	switch t {
+	case "integer":
+		// Any number without a fractional part, including 1.0
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return jsonTypeOf(v) == t
+	}
+}
+
+// jsonTypeOf names the JSON type of a decoded value
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// jsonEqual compares an enum entry with a decoded value
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,153 @@
+package claude
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decode parses JSON the way answers are decoded before validation
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"status": {"type": "string", "enum": ["up", "down"]},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"note": {"type": ["string", "null"]},
+			"items": {"type": "array", "items": {"type": "boolean"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["status", "count"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		err   string // substring of the expected error, "" for valid
+	}{
+		{`{"status": "up", "count": 3}`, ""},
+		{`{"status": "down", "count": 1.0, "ratio": 0.5, "note": null}`, ""},
+		{`{"status": "up", "count": 0, "note": "x", "items": [true, false], "labels": {"a": "b"}}`, ""},
+		{`{"status": "up"}`, `$: missing required property "count"`},
+		{`{"status": "sideways", "count": 1}`, `$.status: value sideways is not one of`},
+		{`{"status": "up", "count": 1.5}`, `$.count: expected integer, got number`},
+		{`{"status": "up", "count": "1"}`, `$.count: expected integer, got string`},
+		{`{"status": "up", "count": 1, "note": 2}`, `$.note: expected string or null, got number`},
+		{`{"status": "up", "count": 1, "items": [true, 1]}`, `$.items[1]: expected boolean, got number`},
+		{`{"status": "up", "count": 1, "labels": {"a": 1}}`, `$.labels.a: expected string, got number`},
+		{`{"status": "up", "count": 1, "extra": true}`, `$: unexpected property "extra"`},
+		{`[]`, `$: expected object, got array`},
+	}
+
+	for _, tt := range tests {
+		err := schema.Validate(decode(t, tt.value))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("Validate(%s) = %v, want valid", tt.value, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("Validate(%s) = %v, want error containing %q", tt.value, err, tt.err)
+		}
+	}
+}
+
+type embedded struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type optional struct {
+	Hint string `json:"hint"`
+}
+
+type answer struct {
+	embedded
+	*optional
+	Status   string            `json:"status" description:"up or down"`
+	Checked  time.Time         `json:"checked"`
+	Note     *string           `json:"note"`
+	Raw      []byte            `json:"raw"`
+	Scores   []float64         `json:"scores,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Next     *answer           `json:"next,omitempty"`
+	Children []answer          `json:"children,omitempty"`
+	Ignored  string            `json:"-"`
+	Comment  string            `json:"comment"` // shadows the embedded field
+	private  string
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor(&answer{})
+
+	want := []string{"id", "status", "checked", "raw", "comment"}
+	slices.Sort(want)
+	required := slices.Clone(s.Required)
+	slices.Sort(required)
+	if !slices.Equal(required, want) {
+		t.Errorf("required = %v, want %v", required, want)
+	}
+
+	for name, typ := range map[string]any{
+		"id":      "integer",
+		"hint":    "string",
+		"status":  "string",
+		"checked": "string",
+		"raw":     "string",
+		"scores":  "array",
+		"labels":  "object",
+		"comment": "string",
+	} {
+		prop, ok := s.Properties[name]
+		if !ok {
+			t.Errorf("missing property %q", name)
+			continue
+		}
+		if prop.Type != typ {
+			t.Errorf("%s type = %v, want %v", name, prop.Type, typ)
+		}
+	}
+	for _, name := range []string{"Ignored", "private", "embedded", "optional"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("unexpected property %q", name)
+		}
+	}
+
+	if s.Properties["status"].Description != "up or down" {
+		t.Errorf("status description = %q", s.Properties["status"].Description)
+	}
+	if got := s.Properties["note"].Type; !slices.Equal(got.([]string), []string{"string", "null"}) {
+		t.Errorf("note type = %v, want nullable string", got)
+	}
+	// Recursion stops at a plain object
+	if next := s.Properties["next"]; next.Properties != nil || !slices.Equal(next.Type.([]string), []string{"object", "null"}) {
+		t.Errorf("next = %+v, want a nullable object without properties", next)
+	}
+	if items := s.Properties["children"].Items; items == nil || items.Type != "object" || items.Properties != nil {
+		t.Errorf("children items = %+v, want a plain object", items)
+	}
+
+	// What encoding/json produces for the type validates against it
+	data, err := json.Marshal(answer{Status: "up", Raw: []byte("hi"), Next: &answer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(decode(t, string(data))); err != nil {
+		t.Errorf("Validate(%s) = %v", data, err)
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/cron"
+	"github.com/murr/check-and-ping/internal/probe"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+const (
+	defaultCheckInterval  = time.Minute
+	defaultHeartbeatGrace = 5 * time.Minute
+)
+
+// CheckConfig declares a check in config.yaml instead of Go code
+type CheckConfig struct {
+	Name     string        `yaml:"name"`
+	Type     string        `yaml:"type"`     // "http" (default) or "heartbeat"
+	Interval time.Duration `yaml:"interval"` // e.g. "30s", "5m" (defaults to 1m)
+	Schedule string        `yaml:"schedule"` // cron expression, overrides interval
+	Timeout  time.Duration `yaml:"timeout"`  // per-run timeout, overrides check_timeout
+
+	// Alert options
+	Title    string   `yaml:"title,omitempty"`
+	Priority string   `yaml:"priority,omitempty"` // low, normal, high, urgent
+	Tags     []string `yaml:"tags,omitempty"`
+
+	DisableRecovery bool            `yaml:"disable_recovery,omitempty"` // don't notify when the check recovers
+	Renotify        *RenotifyConfig `yaml:"renotify,omitempty"`         // overrides the global renotify policy
+
+	FailureThreshold int        `yaml:"failure_threshold,omitempty"` // consecutive failures before alerting (default 1)
+	SuccessThreshold int        `yaml:"success_threshold,omitempty"` // consecutive successes before resolving (default 1)
+	Flap             FlapConfig `yaml:"flap,omitempty"`
+	AlertOnError     bool       `yaml:"alert_on_error,omitempty"` // alert UNKNOWN when the check errors instead of only backing off
+
+	// HTTP probe options
+	URL            string            `yaml:"url,omitempty"`
+	Method         string            `yaml:"method,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+	Body           string            `yaml:"body,omitempty"`
+	ExpectedStatus []int             `yaml:"expected_status,omitempty"` // defaults to any 2xx
+	MaxLatency     time.Duration     `yaml:"max_latency,omitempty"`
+	BodyContains   string            `yaml:"body_contains,omitempty"`
+	BodyRegex      string            `yaml:"body_regex,omitempty"`
+	JSONPath       string            `yaml:"json_path,omitempty"`  // dotted path, e.g. "status.indicator"
+	JSONValue      string            `yaml:"json_value,omitempty"` // expected value at json_path
+
+	// Heartbeat options (pings arrive at /ping/<name> on the status server)
+	Period time.Duration `yaml:"period,omitempty"` // how often the job pings
+	Grace  time.Duration `yaml:"grace,omitempty"`  // how late a ping may be (defaults to 5m)
+}
+
+// FlapConfig configures flap detection for a check
+type FlapConfig struct {
+	Window     time.Duration `yaml:"window"`      // e.g. "30m"
+	MaxChanges int           `yaml:"max_changes"` // status changes within window that count as flapping
+}
+
+// validate checks a single check config for required fields
+func (cc *CheckConfig) validate() error {
+	if cc.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if cc.Interval < 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if cc.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if cc.Schedule != "" {
+		if _, err := cron.Parse(cc.Schedule); err != nil {
+			return err
+		}
+	}
+	if _, err := check.ParsePriority(cc.Priority); err != nil {
+		return err
+	}
+	if cc.Renotify != nil {
+		if err := cc.Renotify.validate(); err != nil {
+			return err
+		}
+	}
+	if cc.FailureThreshold < 0 || cc.SuccessThreshold < 0 {
+		return fmt.Errorf("failure_threshold and success_threshold must be positive")
+	}
+	if cc.Flap.MaxChanges < 0 || cc.Flap.Window < 0 {
+		return fmt.Errorf("flap window and max_changes must be positive")
+	}
+	if (cc.Flap.MaxChanges > 0) != (cc.Flap.Window > 0) {
+		return fmt.Errorf("flap requires both window and max_changes")
+	}
+
+	switch cc.Type {
+	case "http", "":
+		if cc.URL == "" {
+			return fmt.Errorf("http check requires url")
+		}
+		if !strings.HasPrefix(cc.URL, "http://") && !strings.HasPrefix(cc.URL, "https://") {
+			return fmt.Errorf("url must start with http:// or https://")
+		}
+		if cc.BodyRegex != "" {
+			if _, err := regexp.Compile(cc.BodyRegex); err != nil {
+				return fmt.Errorf("invalid body_regex: %w", err)
+			}
+		}
+		if cc.JSONValue != "" && cc.JSONPath == "" {
+			return fmt.Errorf("json_value requires json_path")
+		}
+	case "heartbeat":
+		if cc.Period <= 0 {
+			return fmt.Errorf("heartbeat check requires a positive period")
+		}
+		if cc.Grace < 0 {
+			return fmt.Errorf("grace must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown type: %s", cc.Type)
+	}
+
+	return nil
+}
+
+// Check builds a check.Check from the config. Call Validate first.
+// Heartbeat checks read their pings from pings.
+func (cc *CheckConfig) Check(pings state.Pings) (check.Check, error) {
+	interval := cc.Interval
+	if interval == 0 {
+		interval = defaultCheckInterval
+	}
+
+	priority, err := check.ParsePriority(cc.Priority)
+	if err != nil {
+		return check.Check{}, err
+	}
+
+	var chk check.Check
+	switch cc.Type {
+	case "http", "":
+		p := &probe.HTTP{
+			URL:            cc.URL,
+			Method:         strings.ToUpper(cc.Method),
+			Headers:        cc.Headers,
+			Body:           cc.Body,
+			ExpectedStatus: cc.ExpectedStatus,
+			MaxLatency:     cc.MaxLatency,
+			BodyContains:   cc.BodyContains,
+			JSONPath:       cc.JSONPath,
+			JSONValue:      cc.JSONValue,
+			Title:          cc.Title,
+			Priority:       priority,
+			Tags:           cc.Tags,
+		}
+		if cc.BodyRegex != "" {
+			re, err := regexp.Compile(cc.BodyRegex)
+			if err != nil {
+				return check.Check{}, fmt.Errorf("invalid body_regex: %w", err)
+			}
+			p.BodyRegex = re
+		}
+		chk = p.Check(cc.Name, interval)
+	case "heartbeat":
+		grace := cc.Grace
+		if grace == 0 {
+			grace = defaultHeartbeatGrace
+		}
+		p := &probe.Heartbeat{
+			Pings:    pings,
+			Period:   cc.Period,
+			Grace:    grace,
+			Title:    cc.Title,
+			Priority: priority,
+			Tags:     cc.Tags,
+		}
+		chk = p.Check(cc.Name, interval)
+	default:
+		return check.Check{}, fmt.Errorf("unknown check type: %s", cc.Type)
+	}
+
+	chk.Schedule = cc.Schedule
+	chk.Timeout = cc.Timeout
+	chk.DisableRecovery = cc.DisableRecovery
+	chk.FailureThreshold = cc.FailureThreshold
+	chk.SuccessThreshold = cc.SuccessThreshold
+	chk.Flap = check.FlapPolicy{Window: cc.Flap.Window, MaxChanges: cc.Flap.MaxChanges}
+	chk.AlertOnError = cc.AlertOnError
+	if cc.Renotify != nil {
+		policy := cc.Renotify.Policy()
+		chk.Renotify = &policy
+	}
+	return chk, nil
+}
+
+// BuildChecks builds all checks declared in the config. Heartbeat checks
+// read their pings from pings, which may be nil if the checks won't run.
+func (c *Config) BuildChecks(pings state.Pings) ([]check.Check, error) {
+	checks := make([]check.Check, 0, len(c.Checks))
+	for i := range c.Checks {
+		chk, err := c.Checks[i].Check(pings)
+		if err != nil {
+			return nil, fmt.Errorf("check[%d] %s: %w", i, c.Checks[i].Name, err)
+		}
+		checks = append(checks, chk)
+	}
+	return checks, nil
+}
@@ -2,29 +2,124 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/notifier"
 	"gopkg.in/yaml.v3"
 )
 
 // Config is the root configuration structure
 type Config struct {
-	Claude        ClaudeConfig        `yaml:"claude"`
+	Claude        ClaudeConfig         `yaml:"claude"`
 	Notifications []NotificationConfig `yaml:"notifications"`
 	State         StateConfig          `yaml:"state"`
+	Checks        []CheckConfig        `yaml:"checks"`
+	Renotify      RenotifyConfig       `yaml:"renotify"` // default for all checks
+	Retry         RetryConfig          `yaml:"notification_retry"`
+	Routing       RoutingConfig        `yaml:"routing"`
+	CheckTimeout  time.Duration        `yaml:"check_timeout"` // default per-run timeout (5m if unset)
+	Server        ServerConfig         `yaml:"server"`
 }
 
-// ClaudeConfig configures the Claude CLI client
+// ServerConfig configures the HTTP status API served while running
+type ServerConfig struct {
+	Listen string `yaml:"listen"`          // address such as "127.0.0.1:8080" ("" disables the server)
+	Token  string `yaml:"token,omitempty"` // bearer token required on every request but heartbeat pings when set
+}
+
+// Enabled reports whether the status server should run
+func (s ServerConfig) Enabled() bool {
+	return s.Listen != ""
+}
+
+// RenotifyConfig configures reminders for alerts whose condition persists
+type RenotifyConfig struct {
+	Interval time.Duration `yaml:"interval"`            // repeat after this long (0 disables)
+	MaxCount int           `yaml:"max_count,omitempty"` // max reminders per condition (0 = unlimited)
+	Escalate bool          `yaml:"escalate,omitempty"`  // raise priority on each reminder
+}
+
+// Policy converts the config to a check.RenotifyPolicy
+func (r RenotifyConfig) Policy() check.RenotifyPolicy {
+	return check.RenotifyPolicy{
+		Interval: r.Interval,
+		MaxCount: r.MaxCount,
+		Escalate: r.Escalate,
+	}
+}
+
+// validate checks the renotify settings
+func (r RenotifyConfig) validate() error {
+	if r.Interval < 0 {
+		return fmt.Errorf("renotify interval must be positive")
+	}
+	if r.MaxCount < 0 {
+		return fmt.Errorf("renotify max_count must not be negative")
+	}
+	return nil
+}
+
+// RetryConfig configures retries of notifications that fail temporarily
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts,omitempty"` // attempts before dead-lettering (default 8, 1 disables retries)
+	Backoff     time.Duration `yaml:"backoff,omitempty"`      // first retry delay, doubled each time (default 30s)
+	MaxBackoff  time.Duration `yaml:"max_backoff,omitempty"`  // longest delay between attempts (default 1h)
+}
+
+// Policy converts the config to a notifier.RetryPolicy
+func (r RetryConfig) Policy() notifier.RetryPolicy {
+	return notifier.RetryPolicy{
+		MaxAttempts: r.MaxAttempts,
+		Backoff:     r.Backoff,
+		MaxBackoff:  r.MaxBackoff,
+	}
+}
+
+// validate checks the retry settings
+func (r RetryConfig) validate() error {
+	if r.MaxAttempts < 0 || r.Backoff < 0 || r.MaxBackoff < 0 {
+		return fmt.Errorf("notification_retry: max_attempts, backoff and max_backoff must be positive")
+	}
+	return nil
+}
+
+// ClaudeConfig configures the Claude client
 type ClaudeConfig struct {
-	Disabled bool   `yaml:"disabled"` // Set to true to disable Claude entirely
-	CLIPath  string `yaml:"cli_path"` // Path to claude CLI (defaults to "claude" in PATH)
+	Disabled bool   `yaml:"disabled"`        // Set to true to disable Claude entirely
+	CLIPath  string `yaml:"cli_path"`        // Path to claude CLI (defaults to "claude" in PATH)
+	Model    string `yaml:"model,omitempty"` // Model name (backend default if empty)
+	Backend  string `yaml:"backend"`         // "cli" (default) or "api"
+
+	// Messages API backend options
+	APIKey       string `yaml:"api_key,omitempty"`
+	APIURL       string `yaml:"api_url,omitempty"`    // defaults to https://api.anthropic.com
+	MaxTokens    int    `yaml:"max_tokens,omitempty"` // default 4096
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
+
+	Cache ClaudeCacheConfig `yaml:"cache"`
+
+	// Limits on CLI invocations across all checks
+	MaxConcurrent int           `yaml:"max_concurrent,omitempty"` // calls running at once (0 = unlimited)
+	MaxRequests   int           `yaml:"max_requests,omitempty"`   // calls started per request_window (0 = unlimited)
+	RequestWindow time.Duration `yaml:"request_window,omitempty"` // default 1m
+	MaxWait       time.Duration `yaml:"max_wait,omitempty"`       // queue time before a run is retried later (0 = as long as the check timeout allows)
+}
+
+// ClaudeCacheConfig configures caching of identical Claude requests
+type ClaudeCacheConfig struct {
+	Type string        `yaml:"type"` // "memory", "sqlite" (uses the state db) or "" to disable
+	TTL  time.Duration `yaml:"ttl"`  // how long responses are reused (default 1h)
 }
 
 // NotificationConfig configures a notification channel
 type NotificationConfig struct {
 	Type string `yaml:"type"`
+	Name string `yaml:"name,omitempty"` // used by routing rules (defaults to type)
 
 	// ntfy.sh options
 	Topic  string `yaml:"topic,omitempty"`
@@ -39,12 +134,34 @@ type NotificationConfig struct {
 	// SendGrid options
 	APIKey   string `yaml:"api_key,omitempty"`
 	FromName string `yaml:"from_name,omitempty"`
+
+	// Webhook options
+	URL           string            `yaml:"url,omitempty"`
+	Method        string            `yaml:"method,omitempty"` // defaults to POST
+	Headers       map[string]string `yaml:"headers,omitempty"`
+	Template      string            `yaml:"template,omitempty"`       // text/template body rendered from the alert
+	SuccessStatus []int             `yaml:"success_status,omitempty"` // defaults to any 2xx
+
+	// Slack options (url is the incoming webhook URL; or use token and channel)
+	Token    string `yaml:"token,omitempty"`
+	Channel  string `yaml:"channel,omitempty"`
+	CheckURL string `yaml:"check_url,omitempty"` // link on each message, "{check}" is replaced with the check name
+	APIURL   string `yaml:"api_url,omitempty"`   // overrides https://slack.com/api
+}
+
+// NotifierName returns the name routing rules use for this notifier
+func (n NotificationConfig) NotifierName() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Type
 }
 
 // StateConfig configures state persistence
 type StateConfig struct {
-	Type   string `yaml:"type"` // "memory" or "sqlite"
-	DBPath string `yaml:"db_path,omitempty"`
+	Type      string        `yaml:"type"` // "memory" or "sqlite"
+	DBPath    string        `yaml:"db_path,omitempty"`
+	Retention time.Duration `yaml:"retention,omitempty"` // sqlite history retention (defaults to 30 days, negative keeps forever)
 }
 
 // Load reads and parses a config file, expanding environment variables
@@ -104,8 +221,52 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("sqlite state requires db_path")
 	}
 
-	// Validate notification configs
+	// Validate Claude backend
+	switch c.Claude.Backend {
+	case "cli", "":
+		c.Claude.Backend = "cli"
+	case "api":
+		if c.Claude.APIKey == "" && !c.Claude.Disabled {
+			return fmt.Errorf("claude: api backend requires api_key")
+		}
+	default:
+		return fmt.Errorf("claude: unknown backend: %s", c.Claude.Backend)
+	}
+	if c.Claude.MaxTokens < 0 {
+		return fmt.Errorf("claude: max_tokens must be positive")
+	}
+
+	// Validate Claude cache config
+	switch c.Claude.Cache.Type {
+	case "", "memory":
+		// OK
+	case "sqlite":
+		if c.State.Type != "sqlite" {
+			return fmt.Errorf("claude.cache: sqlite cache requires sqlite state")
+		}
+	default:
+		return fmt.Errorf("claude.cache: unknown type: %s", c.Claude.Cache.Type)
+	}
+	if c.Claude.Cache.TTL < 0 {
+		return fmt.Errorf("claude.cache: ttl must be positive")
+	}
+	if c.Claude.MaxConcurrent < 0 || c.Claude.MaxRequests < 0 || c.Claude.RequestWindow < 0 || c.Claude.MaxWait < 0 {
+		return fmt.Errorf("claude: limits must be positive")
+	}
+	if c.Claude.Cache.Type != "" && c.Claude.Cache.TTL == 0 {
+		c.Claude.Cache.TTL = time.Hour
+	}
+
+	// Validate notification configs. Names must be unique even without
+	// routing, since deliveries and retries are tracked per notifier name.
+	notifierNames := make(map[string]bool, len(c.Notifications))
 	for i, n := range c.Notifications {
+		name := n.NotifierName()
+		if notifierNames[name] {
+			return fmt.Errorf("notification[%d]: duplicate name %q (set name: to tell notifiers apart)", i, name)
+		}
+		notifierNames[name] = true
+
 		switch n.Type {
 		case "stdout":
 			// No validation needed
@@ -121,10 +282,62 @@ func (c *Config) Validate() error {
 			if n.APIKey == "" || n.From == "" || n.To == "" {
 				return fmt.Errorf("notification[%d]: sendgrid requires api_key, from, and to", i)
 			}
+		case "webhook":
+			if n.URL == "" {
+				return fmt.Errorf("notification[%d]: webhook requires url", i)
+			}
+			if _, err := notifier.ParseWebhookTemplate(n.Template); err != nil {
+				return fmt.Errorf("notification[%d]: webhook template: %w", i, err)
+			}
+		case "slack":
+			if n.URL == "" && n.Token == "" {
+				return fmt.Errorf("notification[%d]: slack requires url, or token and channel", i)
+			}
+			if n.Token != "" && n.Channel == "" {
+				return fmt.Errorf("notification[%d]: slack token requires channel", i)
+			}
 		default:
 			return fmt.Errorf("notification[%d]: unknown type: %s", i, n.Type)
 		}
 	}
 
+	if c.CheckTimeout < 0 {
+		return fmt.Errorf("check_timeout must be positive")
+	}
+
+	if c.Server.Enabled() {
+		if _, _, err := net.SplitHostPort(c.Server.Listen); err != nil {
+			return fmt.Errorf("server: invalid listen address %q: %w", c.Server.Listen, err)
+		}
+	}
+
+	if err := c.validateRouting(); err != nil {
+		return err
+	}
+
+	if err := c.Renotify.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Retry.validate(); err != nil {
+		return err
+	}
+
+	// Validate declarative checks
+	names := make(map[string]bool)
+	for i := range c.Checks {
+		cc := &c.Checks[i]
+		if err := cc.validate(); err != nil {
+			return fmt.Errorf("check[%d]: %w", i, err)
+		}
+		if names[cc.Name] {
+			return fmt.Errorf("check[%d]: duplicate name: %s", i, cc.Name)
+		}
+		names[cc.Name] = true
+		if cc.Type == "heartbeat" && !c.Server.Enabled() {
+			return fmt.Errorf("check[%d]: heartbeat checks need server.listen to receive pings", i)
+		}
+	}
+
 	return nil
 }
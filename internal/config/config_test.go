@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateNotifications(t *testing.T) {
+	tests := []struct {
+		name string
+		n    NotificationConfig
+		err  string // substring of the expected error, "" for valid
+	}{
+		{"stdout", NotificationConfig{Type: "stdout"}, ""},
+		{"ntfy without topic", NotificationConfig{Type: "ntfy"}, "ntfy requires topic"},
+		{"webhook bad template", NotificationConfig{Type: "webhook", URL: "http://x", Template: "{{"}, "webhook template"},
+		{"slack webhook", NotificationConfig{Type: "slack", URL: "https://hooks.slack.com/x"}, ""},
+		{"slack bot", NotificationConfig{Type: "slack", Token: "xoxb", Channel: "#alerts"}, ""},
+		{"slack empty", NotificationConfig{Type: "slack"}, "slack requires url, or token and channel"},
+		{"slack token without channel", NotificationConfig{Type: "slack", Token: "xoxb"}, "slack token requires channel"},
+		{"slack url and token without channel", NotificationConfig{Type: "slack", URL: "https://hooks.slack.com/x", Token: "xoxb"}, "slack token requires channel"},
+		{"unknown", NotificationConfig{Type: "pager"}, "unknown type: pager"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Notifications: []NotificationConfig{tt.n}}
+			err := cfg.Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Validate = %v, want valid", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Validate = %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateNotifierNamesUnique(t *testing.T) {
+	ntfy := func(name, topic string) NotificationConfig {
+		return NotificationConfig{Type: "ntfy", Name: name, Topic: topic}
+	}
+
+	// Deliveries are tracked by name, so duplicates are rejected even without routing
+	cfg := &Config{Notifications: []NotificationConfig{ntfy("", "ops"), ntfy("", "oncall")}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `duplicate name "ntfy"`) {
+		t.Errorf("Validate = %v, want duplicate name error", err)
+	}
+
+	cfg = &Config{Notifications: []NotificationConfig{ntfy("", "ops"), ntfy("ntfy-oncall", "oncall")}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate = %v, want distinct names to pass", err)
+	}
+
+	cfg = &Config{Notifications: []NotificationConfig{ntfy("pager", "ops"), {Type: "stdout", Name: "pager"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate succeeded with a name shared across types")
+	}
+}
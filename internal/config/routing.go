@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/notifier"
+)
+
+// RoutingConfig selects which named notifiers receive each alert
+type RoutingConfig struct {
+	Routes  []RouteConfig `yaml:"routes"`
+	Default []string      `yaml:"default,omitempty"` // notifiers for unmatched alerts (all if empty)
+}
+
+// RouteConfig matches alerts and sends them to a subset of notifiers.
+// All set conditions must match.
+type RouteConfig struct {
+	MinPriority string   `yaml:"min_priority,omitempty"` // low, normal, high, urgent
+	MaxPriority string   `yaml:"max_priority,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`        // alert has any of these tags
+	Check       string   `yaml:"check,omitempty"`       // glob on the check name, e.g. "court-*"
+	CheckRegex  string   `yaml:"check_regex,omitempty"` // regex on the check name
+	Notify      []string `yaml:"notify"`                // notifier names
+	Continue    bool     `yaml:"continue,omitempty"`    // keep evaluating later routes after a match
+}
+
+// Enabled reports whether any routing is configured
+func (r RoutingConfig) Enabled() bool {
+	return len(r.Routes) > 0 || len(r.Default) > 0
+}
+
+// Route converts the config to a notifier.Route
+func (rc RouteConfig) Route() (notifier.Route, error) {
+	route := notifier.Route{
+		Tags:      rc.Tags,
+		CheckGlob: rc.Check,
+		Notifiers: rc.Notify,
+		Continue:  rc.Continue,
+	}
+
+	if rc.MinPriority != "" {
+		p, err := check.ParsePriority(rc.MinPriority)
+		if err != nil {
+			return notifier.Route{}, fmt.Errorf("min_priority: %w", err)
+		}
+		route.MinPriority = &p
+	}
+	if rc.MaxPriority != "" {
+		p, err := check.ParsePriority(rc.MaxPriority)
+		if err != nil {
+			return notifier.Route{}, fmt.Errorf("max_priority: %w", err)
+		}
+		route.MaxPriority = &p
+	}
+	if rc.Check != "" {
+		if _, err := path.Match(rc.Check, ""); err != nil {
+			return notifier.Route{}, fmt.Errorf("invalid check glob %q: %w", rc.Check, err)
+		}
+	}
+	if rc.CheckRegex != "" {
+		re, err := regexp.Compile(rc.CheckRegex)
+		if err != nil {
+			return notifier.Route{}, fmt.Errorf("invalid check_regex: %w", err)
+		}
+		route.CheckRegex = re
+	}
+
+	return route, nil
+}
+
+// BuildRoutes converts all route configs
+func (r RoutingConfig) BuildRoutes() ([]notifier.Route, error) {
+	routes := make([]notifier.Route, 0, len(r.Routes))
+	for i, rc := range r.Routes {
+		route, err := rc.Route()
+		if err != nil {
+			return nil, fmt.Errorf("route[%d]: %w", i, err)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// validateRouting checks that routes are well formed and only reference
+// notifiers that exist
+func (c *Config) validateRouting() error {
+	if !c.Routing.Enabled() {
+		return nil
+	}
+
+	names := make(map[string]bool, len(c.Notifications))
+	for _, n := range c.Notifications {
+		names[n.NotifierName()] = true
+	}
+
+	for i, rc := range c.Routing.Routes {
+		if len(rc.Notify) == 0 {
+			return fmt.Errorf("routing.routes[%d]: notify is required", i)
+		}
+		if _, err := rc.Route(); err != nil {
+			return fmt.Errorf("routing.routes[%d]: %w", i, err)
+		}
+		for _, name := range rc.Notify {
+			if !names[name] {
+				return fmt.Errorf("routing.routes[%d]: unknown notifier %q", i, name)
+			}
+		}
+	}
+
+	for _, name := range c.Routing.Default {
+		if !names[name] {
+			return fmt.Errorf("routing.default: unknown notifier %q", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	expr string
+	loc  *time.Location
+
+	second, minute, hour, dom, month, dow uint64
+
+	// domStar/dowStar record an unrestricted day field, which changes
+	// how day-of-month and day-of-week combine (see dayMatches)
+	domStar, dowStar bool
+}
+
+// bounds describes the valid range and names for one cron field
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondBounds = bounds{0, 59, nil}
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week accepts 0-7, where both 0 and 7 mean Sunday
+	dowBounds = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors maps the @-shorthands to their 5-field equivalents
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard cron expression.
+//
+// Five fields are minute, hour, day of month, month and day of week; a sixth
+// leading field adds seconds. Fields accept *, ?, lists, ranges, steps and
+// three-letter month/day names. The @yearly, @monthly, @weekly, @daily and
+// @hourly shorthands are also accepted. A leading "CRON_TZ=<zone>" or
+// "TZ=<zone>" sets the time zone (local time otherwise), e.g.
+//
+//	CRON_TZ=America/Los_Angeles 0 9 * * MON-FRI
+func Parse(expr string) (*Schedule, error) {
+	s := &Schedule{expr: expr, loc: time.Local}
+
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: time zone: %w", expr, err)
+		}
+		s.loc = loc
+		spec = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		d, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron %q: unknown descriptor %s", expr, spec)
+		}
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron %q: expected 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	parse := func(field string, b bounds, name string) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseField(field, b)
+		if err != nil {
+			err = fmt.Errorf("cron %q: %s: %w", expr, name, err)
+		}
+		return bits
+	}
+
+	s.second = parse(fields[0], secondBounds, "second")
+	s.minute = parse(fields[1], minuteBounds, "minute")
+	s.hour = parse(fields[2], hourBounds, "hour")
+	s.dom = parse(fields[3], domBounds, "day of month")
+	s.month = parse(fields[4], monthBounds, "month")
+	s.dow = parse(fields[5], dowBounds, "day of week")
+	if err != nil {
+		return nil, err
+	}
+
+	// Fold 7 (Sunday) onto 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	s.domStar = isStar(fields[3])
+	s.dowStar = isStar(fields[5])
+
+	return s, nil
+}
+
+// MustParse is like Parse but panics on an invalid expression
+func MustParse(expr string) *Schedule {
+	s, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the original expression
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Location returns the time zone the schedule is evaluated in
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next returns the first activation time strictly after t, or the zero
+// time if the schedule never fires within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	// Start at the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	// added tracks whether a field was advanced, after which all
+	// lower fields are reset to their minimum
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// Across a DST change midnight may land on 23:00 or 01:00
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches applies the standard cron rule: when both day fields are
+// restricted, a day matches if either does; otherwise both must match.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// isStar reports whether a field is unrestricted
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField parses a comma-separated cron field into a bitmask
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		r, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+// parseRange parses one element of a field: *, N, N-M, each with an optional /step
+func parseRange(part string, b bounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	var start, end int
+	if rangePart == "*" || rangePart == "?" {
+		start, end = b.min, b.max
+	} else {
+		lo, hi, isRange := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = parseValue(lo, b); err != nil {
+			return 0, err
+		}
+		end = start
+		if isRange {
+			if end, err = parseValue(hi, b); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			// "N/step" means from N to the end of the range
+			end = b.max
+		}
+	}
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepPart)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+
+	if start > end {
+		return 0, fmt.Errorf("invalid range %q", rangePart)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or name, checking it against the field bounds
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return v, nil
+}
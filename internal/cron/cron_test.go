@@ -0,0 +1,150 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@fortnightly",
+		"CRON_TZ=Nowhere/Special 0 9 * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		t.Helper()
+		tm, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2026-01-01 00:00:00", "2026-01-01 00:01:00"},
+		{"* * * * *", "2026-01-01 00:00:30", "2026-01-01 00:01:00"},
+		{"*/15 * * * * *", "2026-01-01 00:00:14", "2026-01-01 00:00:15"},
+		{"0 9 * * *", "2026-01-01 09:00:00", "2026-01-02 09:00:00"},
+		{"0 9 * * MON-FRI", "2026-01-02 10:00:00", "2026-01-05 09:00:00"}, // Friday -> Monday
+		{"0 0 31 * *", "2026-02-01 00:00:00", "2026-03-31 00:00:00"},
+		{"0 0 29 2 *", "2026-01-01 00:00:00", "2028-02-29 00:00:00"},
+		{"0 0 * * 7", "2026-01-01 00:00:00", "2026-01-04 00:00:00"}, // 7 is Sunday
+		{"@monthly", "2026-01-15 12:00:00", "2026-02-01 00:00:00"},
+		{"@hourly", "2026-12-31 23:30:00", "2027-01-01 00:00:00"},
+		// Both day fields restricted: either may match
+		{"0 0 13 * FRI", "2026-01-01 00:00:00", "2026-01-02 00:00:00"},
+		{"0 0 13 * FRI", "2026-01-10 00:00:00", "2026-01-13 00:00:00"},
+		{"0 0 1,15 * *", "2026-01-01 00:00:00", "2026-01-15 00:00:00"},
+		{"30 8-10/2 * * *", "2026-01-01 08:31:00", "2026-01-01 10:30:00"},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse("CRON_TZ=UTC " + tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		got := s.Next(utc(tt.from))
+		if want := utc(tt.want); !got.Equal(want) {
+			t.Errorf("%q: Next(%s) = %s, want %s", tt.expr, tt.from, got.UTC().Format(time.DateTime), tt.want)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s := MustParse("CRON_TZ=UTC 0 0 30 2 *")
+	if got := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next = %s, want zero time for February 30th", got)
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	s := MustParse("CRON_TZ=UTC 0 9 * * *")
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	got := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, loc))
+	if got.Location() != loc {
+		t.Errorf("Next returned location %s, want %s", got.Location(), loc)
+	}
+	if want := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, ny)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			// Clocks jump from 02:00 EST to 03:00 EDT on March 8th
+			name: "daily across spring forward",
+			expr: "0 9 * * *",
+			from: at(time.March, 7, 10, 0),
+			want: at(time.March, 8, 9, 0),
+		},
+		{
+			name: "skipped hour",
+			expr: "30 2 * * *",
+			from: at(time.March, 8, 0, 0),
+			want: at(time.March, 9, 2, 30),
+		},
+		{
+			name: "hourly across spring forward",
+			expr: "0 * * * *",
+			from: at(time.March, 8, 1, 30),
+			want: time.Date(2026, time.March, 8, 7, 0, 0, 0, time.UTC), // 03:00 EDT
+		},
+		{
+			// Clocks fall back from 02:00 EDT to 01:00 EST on November 1st
+			name: "daily across fall back",
+			expr: "0 9 * * *",
+			from: at(time.October, 31, 10, 0),
+			want: at(time.November, 1, 9, 0),
+		},
+		{
+			name: "midnight after fall back",
+			expr: "0 0 * * *",
+			from: at(time.November, 1, 12, 0),
+			want: at(time.November, 2, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := MustParse("CRON_TZ=America/New_York " + tt.expr)
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.In(ny), tt.want.In(ny))
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package metrics is a small registry of counters, gauges and histograms
+// exposed in the Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metric families and writes them in the Prometheus text
+// format. A nil *Registry hands out nil metrics, which record nothing.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is a metric name with its labeled series
+type family struct {
+	name    string
+	help    string
+	kind    string // "counter", "gauge" or "histogram"
+	labels  []string
+	buckets []float64 // histogram upper bounds, ascending
+	series  map[string]*series
+}
+
+// series is one combination of label values
+type series struct {
+	labelValues []string
+	value       float64  // counter or gauge value
+	counts      []uint64 // histogram observations per bucket (not cumulative)
+	sum         float64
+	count       uint64
+}
+
+// register returns the named family, creating it on first use. Registering
+// a name again with a different type or labels is a programming error.
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.kind != kind || !slices.Equal(f.labels, labels) {
+			panic(fmt.Sprintf("metrics: %s registered twice with different types or labels", name))
+		}
+		return f
+	}
+
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	r.families[name] = f
+	return f
+}
+
+// get returns the series for labelValues, creating it on first use.
+// The caller must hold r.mu.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// deleteMatching removes every series whose label has value. The caller
+// must hold r.mu.
+func (f *family) deleteMatching(label, value string) {
+	i := slices.Index(f.labels, label)
+	if i < 0 {
+		return
+	}
+	for key, s := range f.series {
+		if s.labelValues[i] == value {
+			delete(f.series, key)
+		}
+	}
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	r *Registry
+	f *family
+}
+
+// Counter registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	if r == nil {
+		return nil
+	}
+	return &Counter{r: r, f: r.register(name, help, "counter", nil, labels)}
+}
+
+// Inc adds one to the series for labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series for labelValues
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if c == nil {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.f.get(labelValues).value += v
+}
+
+// DeleteMatching removes every series whose label has value, e.g. those
+// of a check that no longer exists
+func (c *Counter) DeleteMatching(label, value string) {
+	if c == nil {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.f.deleteMatching(label, value)
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	r *Registry
+	f *family
+}
+
+// Gauge registers a gauge with the given label names
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	if r == nil {
+		return nil
+	}
+	return &Gauge{r: r, f: r.register(name, help, "gauge", nil, labels)}
+}
+
+// Set sets the series for labelValues to v
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.get(labelValues).value = v
+}
+
+// DeleteMatching removes every series whose label has value
+func (g *Gauge) DeleteMatching(label, value string) {
+	if g == nil {
+		return
+	}
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.deleteMatching(label, value)
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	r *Registry
+	f *family
+}
+
+// Histogram registers a histogram with the given bucket upper bounds and
+// label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if r == nil {
+		return nil
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Histogram{r: r, f: r.register(name, help, "histogram", buckets, labels)}
+}
+
+// Observe records v in the series for labelValues
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+
+	s := h.f.get(labelValues)
+	if i, _ := slices.BinarySearch(h.f.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// DeleteMatching removes every series whose label has value
+func (h *Histogram) DeleteMatching(label, value string) {
+	if h == nil {
+		return
+	}
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+	h.f.deleteMatching(label, value)
+}
+
+// WriteTo writes every metric in the Prometheus text format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		r.families[name].write(&b)
+	}
+	r.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the registry's metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// write appends the family in the text format. The caller must hold the
+// registry's lock.
+func (f *family) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, labelPairs(f.labels, s.labelValues, "", 0), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labelPairs(f.labels, s.labelValues, "le", upper), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labelPairs(f.labels, s.labelValues, "le", math.Inf(1)), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, labelPairs(f.labels, s.labelValues, "", 0), formatFloat(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, labelPairs(f.labels, s.labelValues, "", 0), s.count)
+	}
+}
+
+// labelPairs formats {name="value",...}, adding an le label if le is set
+func labelPairs(names, values []string, le string, upper float64) string {
+	if len(names) == 0 && le == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, le+`="`+formatFloat(upper)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StatusError is returned when a notification service answers with an
+// unsuccessful HTTP status
+type StatusError struct {
+	Service    string // e.g. "ntfy"
+	StatusCode int
+	// RetryAfter is how long the service asked us to wait before trying
+	// again (0 if it didn't say)
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.Service, e.StatusCode)
+}
+
+// Temporary reports whether the status is worth retrying: rate limits,
+// request timeouts and server errors
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// statusError builds a StatusError from an unsuccessful response
+func statusError(service string, resp *http.Response) *StatusError {
+	return &StatusError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date, returning 0 if it is missing, invalid or in the past
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// Retryable reports whether a failed delivery may succeed if sent again,
+// and how long the service asked to wait first (0 if it didn't say).
+// Temporary HTTP statuses, timeouts and network errors are retryable;
+// other errors, such as a rejected request or a bad template, are not.
+func Retryable(err error) (bool, time.Duration) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary(), statusErr.RetryAfter
+	}
+
+	var (
+		urlErr *url.Error
+		netErr net.Error
+	)
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true, 0
+	}
+	return false, 0
+}
@@ -3,14 +3,24 @@ package notifier
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/metrics"
 )
 
-// Multi fans out alerts to multiple notifiers
+// SendHook is called after each individual notifier attempts delivery
+type SendHook func(notifier string, alert check.Alert, err error)
+
+// Multi fans out alerts to multiple notifiers. Deliveries are tracked by
+// notifier name, so names must be unique (see Named).
 type Multi struct {
 	notifiers []Notifier
+	hooks     []SendHook
+	outbox    *Outbox
+	sent      *metrics.Counter
+	failed    *metrics.Counter
 }
 
 // NewMulti creates a notifier that sends to all provided notifiers
@@ -31,16 +41,56 @@ func (m *Multi) Name() string {
 
 // Send sends the alert to all notifiers, collecting any errors
 func (m *Multi) Send(ctx context.Context, alert check.Alert) error {
-	var errs []error
+	return m.sendTo(ctx, alert, m.notifiers, nil)
+}
+
+// SendExcept sends the alert to all notifiers not named in skip
+func (m *Multi) SendExcept(ctx context.Context, alert check.Alert, skip []string) error {
+	return m.sendTo(ctx, alert, m.notifiers, skip)
+}
+
+// sendTo sends the alert to the given notifiers, except those named in
+// skip, running hooks after each attempt. The alert supersedes retries the
+// outbox still has queued for the check with the same notifier, and
+// failures the outbox queues for retry are not reported. If any notifier
+// fails, the *MultiError lists which ones succeeded.
+func (m *Multi) sendTo(ctx context.Context, alert check.Alert, notifiers []Notifier, skip []string) error {
+	var (
+		errs      []error
+		failed    []string
+		succeeded []string
+	)
 
-	for _, n := range m.notifiers {
-		if err := n.Send(ctx, alert); err != nil {
+	for _, n := range notifiers {
+		if slices.Contains(skip, n.Name()) {
+			continue
+		}
+		if m.outbox != nil {
+			m.outbox.Cancel(alert.CheckName, n.Name())
+		}
+
+		err := n.Send(ctx, alert)
+		if err != nil {
+			m.failed.Inc(n.Name())
+		} else {
+			m.sent.Inc(n.Name())
+		}
+		for _, hook := range m.hooks {
+			hook(n.Name(), alert, err)
+		}
+		if err != nil && m.outbox != nil && m.outbox.Defer(n.Name(), alert, err) {
+			err = nil
+		}
+		if err != nil {
 			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+			failed = append(failed, n.Name())
+		} else {
+			succeeded = append(succeeded, n.Name())
 		}
 	}
 
 	if len(errs) > 0 {
-		return &MultiError{Errors: errs}
+		return &MultiError{Errors: errs, Failed: failed, Succeeded: succeeded}
 	}
 
 	return nil
@@ -51,9 +101,36 @@ func (m *Multi) Add(n Notifier) {
 	m.notifiers = append(m.notifiers, n)
 }
 
+// Notifiers returns the notifiers in the order they are sent to
+func (m *Multi) Notifiers() []Notifier {
+	return m.notifiers
+}
+
+// OnSend registers a hook that observes every individual delivery attempt
+func (m *Multi) OnSend(hook SendHook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// SetMetrics counts sent and failed notifications per notifier in reg
+func (m *Multi) SetMetrics(reg *metrics.Registry) {
+	m.sent = reg.Counter("checkandping_notifications_sent_total", "Notifications delivered, by notifier.", "notifier")
+	m.failed = reg.Counter("checkandping_notifications_failed_total", "Notifications that failed to deliver, by notifier.", "notifier")
+}
+
+// SetOutbox queues deliveries that fail temporarily on o, which retries
+// them with these notifiers, instead of reporting them as errors
+func (m *Multi) SetOutbox(o *Outbox) {
+	m.outbox = o
+	o.SetNotifiers(m.notifiers...)
+}
+
 // MultiError contains errors from multiple notifiers
 type MultiError struct {
 	Errors []error
+	// Failed names the notifiers that returned Errors, in the same order.
+	// Succeeded names those that delivered the alert or queued it for retry.
+	Failed    []string
+	Succeeded []string
 }
 
 func (e *MultiError) Error() string {
@@ -65,5 +142,8 @@ func (e *MultiError) Error() string {
 		}
 		sb.WriteString(err.Error())
 	}
+	if len(e.Succeeded) > 0 {
+		sb.WriteString(" (delivered by " + strings.Join(e.Succeeded, ", ") + ")")
+	}
 	return sb.String()
 }
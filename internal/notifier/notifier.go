@@ -11,3 +11,29 @@ type Notifier interface {
 	Name() string
 	Send(ctx context.Context, alert check.Alert) error
 }
+
+// Fanout is implemented by notifiers that send to several channels and can
+// leave out the ones that already delivered an alert. When some channels
+// fail, Send and SendExcept return a *MultiError naming the ones that
+// succeeded.
+type Fanout interface {
+	Notifier
+	SendExcept(ctx context.Context, alert check.Alert, skip []string) error
+}
+
+// named overrides the name of a notifier
+type named struct {
+	Notifier
+	name string
+}
+
+// Named wraps a notifier so that Name returns the given name. This lets
+// several notifiers of the same type be told apart in routing and history.
+func Named(name string, n Notifier) Notifier {
+	return &named{Notifier: n, name: name}
+}
+
+// Name returns the configured name
+func (n *named) Name() string {
+	return n.name
+}
@@ -82,7 +82,7 @@ func (n *Ntfy) Send(ctx context.Context, alert check.Alert) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+		return statusError("ntfy", resp)
 	}
 
 	return nil
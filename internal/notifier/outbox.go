@@ -0,0 +1,286 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/metrics"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+const (
+	defaultMaxAttempts  = 8
+	defaultRetryBackoff = 30 * time.Second
+	defaultMaxBackoff   = time.Hour
+	outboxPollInterval  = 5 * time.Second
+	outboxBatchSize     = 50
+)
+
+// RetryPolicy controls how failed deliveries are retried. Zero fields use
+// the defaults.
+type RetryPolicy struct {
+	MaxAttempts int           // attempts, including the first, before dead-lettering (default 8)
+	Backoff     time.Duration // wait before the first retry, doubling after each (default 30s)
+	MaxBackoff  time.Duration // longest wait between attempts (default 1h)
+}
+
+// withDefaults fills in unset fields
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = defaultRetryBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	return p
+}
+
+// Delay returns how long to wait after attempts failed attempts. A
+// Retry-After from the service is honored if it asks for longer.
+func (p RetryPolicy) Delay(attempts int, retryAfter time.Duration) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return max(min(delay, p.MaxBackoff), retryAfter)
+}
+
+// Outbox retries deliveries that failed temporarily. Each is queued for a
+// single notifier in the state backend, so other notifiers aren't sent
+// the alert again and pending deliveries survive a restart. A delivery is
+// dead-lettered once it fails permanently or runs out of attempts.
+type Outbox struct {
+	store  state.Outbox
+	policy RetryPolicy
+	logger *log.Logger
+
+	mu        sync.Mutex
+	notifiers map[string]Notifier
+	hooks     []SendHook
+
+	sent         *metrics.Counter
+	failed       *metrics.Counter
+	deadLettered *metrics.Counter
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// NewOutbox creates an outbox that queues deliveries in store
+func NewOutbox(store state.Outbox, policy RetryPolicy, logger *log.Logger) *Outbox {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Outbox{
+		store:     store,
+		policy:    policy.withDefaults(),
+		logger:    logger,
+		notifiers: make(map[string]Notifier),
+	}
+}
+
+// SetNotifiers sets the notifiers deliveries are retried with, by name.
+// Deliveries for a notifier that no longer exists are dead-lettered.
+// Names must be unique (config.Validate enforces it): deliveries for a
+// name shared by several notifiers can't be told apart, so those
+// notifiers are left out and their deliveries dead-lettered rather than
+// sent to the wrong endpoint.
+func (o *Outbox) SetNotifiers(notifiers ...Notifier) {
+	byName := make(map[string]Notifier, len(notifiers))
+	shared := make(map[string]bool)
+	for _, n := range notifiers {
+		if _, ok := byName[n.Name()]; ok {
+			shared[n.Name()] = true
+		}
+		byName[n.Name()] = n
+	}
+	for name := range shared {
+		o.logger.Printf("outbox: several notifiers are named %q, not retrying their deliveries", name)
+		delete(byName, name)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.notifiers = byName
+}
+
+// OnSend registers a hook that observes every retry
+func (o *Outbox) OnSend(hook SendHook) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.hooks = append(o.hooks, hook)
+}
+
+// SetMetrics counts retries and dead letters per notifier in reg
+func (o *Outbox) SetMetrics(reg *metrics.Registry) {
+	o.sent = reg.Counter("checkandping_notifications_sent_total", "Notifications delivered, by notifier.", "notifier")
+	o.failed = reg.Counter("checkandping_notifications_failed_total", "Notifications that failed to deliver, by notifier.", "notifier")
+	o.deadLettered = reg.Counter("checkandping_notifications_dead_lettered_total",
+		"Notifications given up on after failing permanently or running out of attempts, by notifier.", "notifier")
+}
+
+// Defer queues an alert whose first delivery by the named notifier failed
+// with err, and reports whether it was queued. Errors that retrying won't
+// fix are not queued.
+func (o *Outbox) Defer(notifier string, alert check.Alert, err error) bool {
+	retryable, retryAfter := Retryable(err)
+	if !retryable || o.policy.MaxAttempts <= 1 {
+		return false
+	}
+
+	payload, jsonErr := json.Marshal(alert)
+	if jsonErr != nil {
+		o.logger.Printf("[%s] can't queue %s notification: %v", alert.CheckName, notifier, jsonErr)
+		return false
+	}
+
+	delay := o.policy.Delay(1, retryAfter)
+	_, storeErr := o.store.Enqueue(state.Delivery{
+		Notifier:    notifier,
+		CheckName:   alert.CheckName,
+		Payload:     payload,
+		Attempts:    1,
+		NextAttempt: time.Now().Add(delay),
+		LastError:   err.Error(),
+	})
+	if storeErr != nil {
+		o.logger.Printf("[%s] can't queue %s notification: %v", alert.CheckName, notifier, storeErr)
+		return false
+	}
+
+	o.logger.Printf("[%s] %s notification failed, retrying in %s: %v", alert.CheckName, notifier, delay.Round(time.Second), err)
+	return true
+}
+
+// Cancel drops a check's queued deliveries for notifier, or for every
+// notifier if it is empty, once a newer alert supersedes them
+func (o *Outbox) Cancel(checkName string, notifier string) {
+	n, err := o.store.CancelDeliveries(checkName, notifier)
+	if err != nil {
+		o.logger.Printf("[%s] can't cancel queued notifications: %v", checkName, err)
+		return
+	}
+	if n > 0 {
+		o.logger.Printf("[%s] dropped %d queued notification(s) superseded by a newer alert", checkName, n)
+	}
+}
+
+// Start retries due deliveries in the background until Stop
+func (o *Outbox) Start(ctx context.Context) {
+	ctx, o.stop = context.WithCancel(ctx)
+	o.done = make(chan struct{})
+	go o.run(ctx)
+}
+
+// Stop ends background retries, waiting for an attempt in progress
+func (o *Outbox) Stop() {
+	if o.stop == nil {
+		return
+	}
+	o.stop()
+	<-o.done
+}
+
+// run delivers due deliveries every poll interval
+func (o *Outbox) run(ctx context.Context) {
+	defer close(o.done)
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		o.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue attempts every delivery whose next attempt has come
+func (o *Outbox) deliverDue(ctx context.Context) {
+	due, err := o.store.DueDeliveries(time.Now(), outboxBatchSize)
+	if err != nil {
+		o.logger.Printf("outbox: %v", err)
+		return
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		o.attempt(ctx, d)
+	}
+}
+
+// attempt retries one delivery, then removes, reschedules or dead-letters it
+func (o *Outbox) attempt(ctx context.Context, d state.Delivery) {
+	var alert check.Alert
+	if err := json.Unmarshal(d.Payload, &alert); err != nil {
+		o.deadLetter(d, d.Attempts, fmt.Sprintf("decode alert: %v", err))
+		return
+	}
+
+	o.mu.Lock()
+	n, ok := o.notifiers[d.Notifier]
+	hooks := o.hooks
+	o.mu.Unlock()
+	if !ok {
+		o.deadLetter(d, d.Attempts, "notifier "+d.Notifier+" is no longer configured")
+		return
+	}
+
+	err := n.Send(ctx, alert)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down: leave it queued for the next start
+		return
+	}
+	for _, hook := range hooks {
+		hook(d.Notifier, alert, err)
+	}
+
+	attempts := d.Attempts + 1
+	if err == nil {
+		o.sent.Inc(d.Notifier)
+		if err := o.store.Delivered(d.ID); err != nil {
+			o.logger.Printf("outbox: %v", err)
+		}
+		o.logger.Printf("[%s] %s notification delivered after %d attempts: %s", d.CheckName, d.Notifier, attempts, alert.Title)
+		return
+	}
+	o.failed.Inc(d.Notifier)
+
+	retryable, retryAfter := Retryable(err)
+	if !retryable || attempts >= o.policy.MaxAttempts {
+		o.deadLetter(d, attempts, err.Error())
+		return
+	}
+
+	delay := o.policy.Delay(attempts, retryAfter)
+	if err := o.store.Reschedule(d.ID, attempts, time.Now().Add(delay), err.Error()); err != nil {
+		o.logger.Printf("outbox: %v", err)
+		return
+	}
+	o.logger.Printf("[%s] %s notification failed (attempt %d of %d), retrying in %s: %v",
+		d.CheckName, d.Notifier, attempts, o.policy.MaxAttempts, delay.Round(time.Second), err)
+}
+
+// deadLetter gives up on a delivery
+func (o *Outbox) deadLetter(d state.Delivery, attempts int, reason string) {
+	o.deadLettered.Inc(d.Notifier)
+	if err := o.store.DeadLetter(d.ID, attempts, reason); err != nil {
+		o.logger.Printf("outbox: %v", err)
+		return
+	}
+	o.logger.Printf("[%s] giving up on %s notification after %d attempts: %s", d.CheckName, d.Notifier, attempts, reason)
+}
@@ -0,0 +1,228 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+var (
+	unavailable = &StatusError{Service: "test", StatusCode: http.StatusServiceUnavailable}
+	rejected    = &StatusError{Service: "test", StatusCode: http.StatusBadRequest}
+)
+
+// newTestOutbox returns an outbox retrying immediately, over notifiers in a Multi
+func newTestOutbox(t *testing.T, maxAttempts int, notifiers ...Notifier) (*Outbox, *Multi, *state.Memory) {
+	t.Helper()
+	store := state.NewMemory()
+	o := NewOutbox(store, RetryPolicy{MaxAttempts: maxAttempts, Backoff: time.Nanosecond, MaxBackoff: time.Nanosecond},
+		log.New(io.Discard, "", 0))
+	m := NewMulti(notifiers...)
+	m.SetOutbox(o)
+	return o, m, store
+}
+
+// queued returns the deliveries due now
+func queued(t *testing.T, store *state.Memory) []state.Delivery {
+	t.Helper()
+	time.Sleep(time.Millisecond)
+	due, err := store.DueDeliveries(time.Now(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return due
+}
+
+func TestOutboxRetriesUntilDelivered(t *testing.T) {
+	ok, flaky := &fake{name: "ok"}, &fake{name: "flaky", err: unavailable}
+	o, m, store := newTestOutbox(t, 5, ok, flaky)
+	ctx := context.Background()
+
+	// The failure is queued, so the alert counts as sent
+	if err := m.Send(ctx, check.Alert{CheckName: "web", Title: "Down"}); err != nil {
+		t.Fatalf("Send = %v, want nil once queued", err)
+	}
+	if d := queued(t, store); len(d) != 1 || d[0].Notifier != "flaky" || d[0].Attempts != 1 {
+		t.Fatalf("queued = %+v, want one flaky delivery after 1 attempt", d)
+	}
+
+	o.deliverDue(ctx)
+	if d := queued(t, store); len(d) != 1 || d[0].Attempts != 2 {
+		t.Fatalf("queued = %+v, want one delivery after 2 attempts", d)
+	}
+
+	flaky.err = nil
+	o.deliverDue(ctx)
+	if d := queued(t, store); len(d) != 0 {
+		t.Fatalf("queued = %+v, want none after delivery", d)
+	}
+	if len(ok.alerts) != 1 {
+		t.Errorf("ok notifier got %d alerts, want 1", len(ok.alerts))
+	}
+	if len(flaky.alerts) != 3 || flaky.alerts[2].Title != "Down" {
+		t.Errorf("flaky notifier got %+v, want the alert 3 times", flaky.alerts)
+	}
+}
+
+func TestOutboxDeadLettersAfterMaxAttempts(t *testing.T) {
+	flaky := &fake{name: "flaky", err: unavailable}
+	o, m, store := newTestOutbox(t, 3, flaky)
+	ctx := context.Background()
+
+	m.Send(ctx, check.Alert{CheckName: "web"})
+	o.deliverDue(ctx)
+	o.deliverDue(ctx)
+
+	if d := queued(t, store); len(d) != 0 {
+		t.Fatalf("queued = %+v, want none", d)
+	}
+	letters, err := store.DeadLetters(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 || letters[0].Attempts != 3 || letters[0].LastError != unavailable.Error() {
+		t.Errorf("dead letters = %+v, want one after 3 attempts", letters)
+	}
+	if len(flaky.alerts) != 3 {
+		t.Errorf("sent %d times, want 3", len(flaky.alerts))
+	}
+}
+
+func TestOutboxDeadLettersPermanentFailure(t *testing.T) {
+	flaky := &fake{name: "flaky", err: unavailable}
+	o, m, store := newTestOutbox(t, 5, flaky)
+	ctx := context.Background()
+
+	m.Send(ctx, check.Alert{CheckName: "web"})
+	flaky.err = rejected
+	o.deliverDue(ctx)
+
+	letters, _ := store.DeadLetters(0)
+	if len(letters) != 1 || letters[0].Attempts != 2 {
+		t.Errorf("dead letters = %+v, want one after 2 attempts", letters)
+	}
+}
+
+func TestOutboxDeadLettersRemovedNotifier(t *testing.T) {
+	flaky := &fake{name: "flaky", err: unavailable}
+	o, m, store := newTestOutbox(t, 5, flaky)
+	ctx := context.Background()
+
+	m.Send(ctx, check.Alert{CheckName: "web"})
+	o.SetNotifiers(&fake{name: "other"})
+	o.deliverDue(ctx)
+
+	letters, _ := store.DeadLetters(0)
+	if len(letters) != 1 || letters[0].Notifier != "flaky" {
+		t.Errorf("dead letters = %+v, want the flaky delivery", letters)
+	}
+	if len(flaky.alerts) != 1 {
+		t.Errorf("sent %d times, want 1", len(flaky.alerts))
+	}
+}
+
+func TestOutboxDoesNotQueuePermanentFailure(t *testing.T) {
+	broken := &fake{name: "broken", err: rejected}
+	_, m, store := newTestOutbox(t, 5, broken, &fake{name: "ok"})
+
+	err := m.Send(context.Background(), check.Alert{CheckName: "web"})
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("Send = %v, want *MultiError", err)
+	}
+	if len(multiErr.Failed) != 1 || multiErr.Failed[0] != "broken" || len(multiErr.Succeeded) != 1 {
+		t.Errorf("failed %v, succeeded %v", multiErr.Failed, multiErr.Succeeded)
+	}
+	if d := queued(t, store); len(d) != 0 {
+		t.Errorf("queued = %+v, want none", d)
+	}
+}
+
+func TestOutboxNewerAlertSupersedesQueued(t *testing.T) {
+	flaky := &fake{name: "flaky", err: unavailable}
+	o, m, store := newTestOutbox(t, 5, flaky)
+	ctx := context.Background()
+
+	m.Send(ctx, check.Alert{CheckName: "web", Title: "[CRITICAL] Down"})
+	m.Send(ctx, check.Alert{CheckName: "other", Title: "[CRITICAL] Down"})
+
+	flaky.err = nil
+	m.Send(ctx, check.Alert{CheckName: "web", Title: "[OK] Resolved", Recovery: true})
+
+	d := queued(t, store)
+	if len(d) != 1 || d[0].CheckName != "other" {
+		t.Fatalf("queued = %+v, want only the other check's delivery", d)
+	}
+
+	o.deliverDue(ctx)
+	for _, alert := range flaky.alerts[3:] {
+		if alert.CheckName == "web" {
+			t.Errorf("stale alert delivered after the recovery: %q", alert.Title)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}.withDefaults()
+
+	tests := []struct {
+		attempts   int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{1, 0, time.Second},
+		{2, 0, 2 * time.Second},
+		{4, 0, 8 * time.Second},
+		{5, 0, 10 * time.Second},
+		{50, 0, 10 * time.Second},
+		{1, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempts, tt.retryAfter); got != tt.want {
+			t.Errorf("Delay(%d, %s) = %s, want %s", tt.attempts, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{unavailable, true},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{rejected, false},
+		{context.DeadlineExceeded, true},
+		{errors.New("bad template"), false},
+	}
+	for _, tt := range tests {
+		if got, _ := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxSharedNameIsNotRetried(t *testing.T) {
+	first := &fake{name: "ntfy", err: unavailable}
+	second := &fake{name: "ntfy", err: unavailable}
+	o, m, store := newTestOutbox(t, 5, first, second)
+	ctx := context.Background()
+
+	m.Send(ctx, check.Alert{CheckName: "web"})
+	o.deliverDue(ctx)
+
+	// Neither endpoint gets the other's retry
+	if len(first.alerts) != 1 || len(second.alerts) != 1 {
+		t.Errorf("sent %d and %d times, want 1 each", len(first.alerts), len(second.alerts))
+	}
+	if d := queued(t, store); len(d) != 0 {
+		t.Errorf("queued = %+v, want the ambiguous deliveries dead-lettered", d)
+	}
+}
@@ -0,0 +1,145 @@
+package notifier
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/metrics"
+)
+
+// Route sends alerts that match all of its conditions to a subset of notifiers.
+// Unset conditions match everything.
+type Route struct {
+	MinPriority *check.Priority
+	MaxPriority *check.Priority
+	Tags        []string       // Alert must carry at least one of these tags
+	CheckGlob   string         // path.Match pattern on the check name
+	CheckRegex  *regexp.Regexp // Pattern on the check name
+	Notifiers   []string       // Names of the notifiers to send to
+	Continue    bool           // Keep evaluating later routes after this one matches
+}
+
+// Matches reports whether the alert satisfies every condition of the route.
+// Priorities are compared without reminder escalation.
+func (r Route) Matches(alert check.Alert) bool {
+	priority := alert.RoutePriority()
+	if r.MinPriority != nil && priority < *r.MinPriority {
+		return false
+	}
+	if r.MaxPriority != nil && priority > *r.MaxPriority {
+		return false
+	}
+	if len(r.Tags) > 0 && !slices.ContainsFunc(r.Tags, func(t string) bool {
+		return slices.Contains(alert.Tags, t)
+	}) {
+		return false
+	}
+	if r.CheckGlob != "" {
+		if ok, _ := path.Match(r.CheckGlob, alert.CheckName); !ok {
+			return false
+		}
+	}
+	if r.CheckRegex != nil && !r.CheckRegex.MatchString(alert.CheckName) {
+		return false
+	}
+	return true
+}
+
+// Router sends each alert to the notifiers selected by the first matching
+// routes, falling back to a default set when no route matches
+type Router struct {
+	all      *Multi
+	byName   map[string]Notifier
+	routes   []Route
+	defaults []string
+}
+
+// NewRouter creates a router over the given notifiers, addressed by Name().
+// Routes are evaluated in order; a match stops evaluation unless the route
+// sets Continue. Alerts that match no route go to the defaults, or to every
+// notifier if defaults is empty.
+func NewRouter(routes []Route, defaults []string, notifiers ...Notifier) *Router {
+	byName := make(map[string]Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byName[n.Name()] = n
+	}
+
+	return &Router{
+		all:      NewMulti(notifiers...),
+		byName:   byName,
+		routes:   routes,
+		defaults: defaults,
+	}
+}
+
+// Name returns the names of all notifiers
+func (r *Router) Name() string {
+	return "router" + strings.TrimPrefix(r.all.Name(), "multi")
+}
+
+// Send sends the alert to the notifiers its route selects
+func (r *Router) Send(ctx context.Context, alert check.Alert) error {
+	return r.all.sendTo(ctx, alert, r.Targets(alert), nil)
+}
+
+// SendExcept sends the alert to the notifiers its route selects, except
+// those named in skip
+func (r *Router) SendExcept(ctx context.Context, alert check.Alert, skip []string) error {
+	return r.all.sendTo(ctx, alert, r.Targets(alert), skip)
+}
+
+// Targets returns the notifiers an alert is routed to
+func (r *Router) Targets(alert check.Alert) []Notifier {
+	var names []string
+	matched := false
+
+	for _, route := range r.routes {
+		if !route.Matches(alert) {
+			continue
+		}
+		matched = true
+		names = append(names, route.Notifiers...)
+		if !route.Continue {
+			break
+		}
+	}
+
+	if !matched {
+		if len(r.defaults) == 0 {
+			return r.all.notifiers
+		}
+		names = r.defaults
+	}
+
+	var targets []Notifier
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		n, ok := r.byName[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		targets = append(targets, n)
+	}
+	return targets
+}
+
+// OnSend registers a hook that observes every individual delivery attempt
+func (r *Router) OnSend(hook SendHook) {
+	r.all.OnSend(hook)
+}
+
+// SetMetrics counts sent and failed notifications per notifier in reg
+func (r *Router) SetMetrics(reg *metrics.Registry) {
+	r.all.SetMetrics(reg)
+}
+
+// SetOutbox queues deliveries that fail temporarily on o instead of
+// reporting them as errors
+func (r *Router) SetOutbox(o *Outbox) {
+	r.all.SetOutbox(o)
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/murr/check-and-ping/internal/check"
+)
+
+// fake is a notifier that records alerts and fails with err when set
+type fake struct {
+	name   string
+	err    error
+	alerts []check.Alert
+}
+
+func (f *fake) Name() string { return f.name }
+
+func (f *fake) Send(ctx context.Context, alert check.Alert) error {
+	f.alerts = append(f.alerts, alert)
+	return f.err
+}
+
+func priority(p check.Priority) *check.Priority {
+	return &p
+}
+
+func names(notifiers []Notifier) []string {
+	var out []string
+	for _, n := range notifiers {
+		out = append(out, n.Name())
+	}
+	return out
+}
+
+func TestRouterTargets(t *testing.T) {
+	notifiers := []Notifier{&fake{name: "stdout"}, &fake{name: "slack"}, &fake{name: "twilio"}, &fake{name: "ntfy"}}
+	routes := []Route{
+		{MinPriority: priority(check.PriorityHigh), Notifiers: []string{"twilio"}, Continue: true},
+		{CheckGlob: "court-*", Tags: []string{"court"}, Notifiers: []string{"ntfy", "slack"}},
+		{CheckRegex: regexp.MustCompile(`^db-`), MaxPriority: priority(check.PriorityNormal), Notifiers: []string{"slack", "missing"}},
+		{Tags: []string{"noisy"}, Notifiers: []string{"stdout", "stdout"}},
+	}
+
+	tests := []struct {
+		name  string
+		alert check.Alert
+		want  []string
+	}{
+		{"no route matches", check.Alert{CheckName: "web"}, []string{"stdout"}},
+		{"matched routes replace defaults", check.Alert{CheckName: "web", Priority: check.PriorityHigh}, []string{"twilio"}},
+		{"priority and check", check.Alert{CheckName: "court-1", Priority: check.PriorityUrgent, Tags: []string{"court"}}, []string{"twilio", "ntfy", "slack"}},
+		{"glob without tag", check.Alert{CheckName: "court-1"}, []string{"stdout"}},
+		{"regex", check.Alert{CheckName: "db-main"}, []string{"slack"}},
+		{"max priority", check.Alert{CheckName: "db-main", Priority: check.PriorityHigh}, []string{"twilio"}},
+		{"duplicate names", check.Alert{CheckName: "web", Tags: []string{"noisy"}}, []string{"stdout"}},
+		{
+			"escalated reminder keeps its route",
+			check.Alert{CheckName: "db-main", Priority: check.PriorityUrgent, Escalation: 2, Repeat: 2},
+			[]string{"slack"},
+		},
+	}
+
+	r := NewRouter(routes, []string{"stdout"}, notifiers...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(r.Targets(tt.alert))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterWithoutDefaults(t *testing.T) {
+	notifiers := []Notifier{&fake{name: "stdout"}, &fake{name: "slack"}}
+	r := NewRouter([]Route{{Tags: []string{"x"}, Notifiers: []string{"slack"}}}, nil, notifiers...)
+
+	if got := names(r.Targets(check.Alert{CheckName: "web"})); !slices.Equal(got, []string{"stdout", "slack"}) {
+		t.Errorf("Targets = %v, want every notifier", got)
+	}
+}
+
+func TestRouterSendExcept(t *testing.T) {
+	stdout, slack := &fake{name: "stdout"}, &fake{name: "slack"}
+	r := NewRouter(nil, nil, stdout, slack)
+
+	if err := r.SendExcept(context.Background(), check.Alert{CheckName: "web"}, []string{"stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(stdout.alerts) != 0 || len(slack.alerts) != 1 {
+		t.Errorf("sent %d to stdout and %d to slack, want 0 and 1", len(stdout.alerts), len(slack.alerts))
+	}
+}
@@ -105,7 +105,7 @@ func (s *SendGrid) Send(ctx context.Context, alert check.Alert) error {
 
 	// SendGrid returns 202 Accepted on success
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("sendgrid returned status %d", resp.StatusCode)
+		return statusError("sendgrid", resp)
 	}
 
 	return nil
@@ -0,0 +1,258 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+)
+
+const (
+	defaultSlackAPIURL = "https://slack.com/api"
+
+	// Block Kit limits
+	slackMaxFields      = 10
+	slackMaxContext     = 10
+	slackMaxHeaderChars = 150
+	slackMaxTextChars   = 3000
+)
+
+// Slack posts Block Kit messages to an incoming webhook or via chat.postMessage
+type Slack struct {
+	webhookURL string // Incoming webhook mode
+	token      string // Bot token mode
+	channel    string
+	apiURL     string
+	checkURL   string // Link template; "{check}" is replaced with the check name
+	httpClient *http.Client
+}
+
+// SlackOption configures the Slack notifier
+type SlackOption func(*Slack)
+
+// WithSlackAPIURL overrides the Slack Web API base URL (useful for testing)
+func WithSlackAPIURL(apiURL string) SlackOption {
+	return func(s *Slack) {
+		s.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithSlackCheckURL sets a link shown on each message. "{check}" in the URL
+// is replaced with the check name, e.g. "https://status.example.com/checks/{check}".
+func WithSlackCheckURL(checkURL string) SlackOption {
+	return func(s *Slack) {
+		s.checkURL = checkURL
+	}
+}
+
+// WithSlackHTTPClient sets a custom HTTP client
+func WithSlackHTTPClient(client *http.Client) SlackOption {
+	return func(s *Slack) {
+		s.httpClient = client
+	}
+}
+
+// NewSlackWebhook creates a Slack notifier that posts to an incoming webhook URL
+func NewSlackWebhook(webhookURL string, opts ...SlackOption) *Slack {
+	return newSlack(&Slack{webhookURL: webhookURL}, opts)
+}
+
+// NewSlackBot creates a Slack notifier that posts with chat.postMessage using a bot token
+func NewSlackBot(token, channel string, opts ...SlackOption) *Slack {
+	return newSlack(&Slack{token: token, channel: channel}, opts)
+}
+
+func newSlack(s *Slack, opts []SlackOption) *Slack {
+	s.apiURL = defaultSlackAPIURL
+	s.httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Name returns the notifier name
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Send posts the alert to Slack
+func (s *Slack) Send(ctx context.Context, alert check.Alert) error {
+	payload := s.buildMessage(alert)
+
+	endpoint := s.webhookURL
+	if s.token != "" {
+		endpoint = s.apiURL + "/chat.postMessage"
+		payload["channel"] = s.channel
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError("slack", resp)
+	}
+
+	// chat.postMessage reports failures in the body with a 200 status
+	if s.token != "" {
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("decode slack response: %w", err)
+		}
+		if !result.OK {
+			return fmt.Errorf("slack API error: %s", result.Error)
+		}
+	}
+
+	return nil
+}
+
+// buildMessage renders the alert as Block Kit blocks inside a colored attachment
+func (s *Slack) buildMessage(alert check.Alert) map[string]any {
+	emoji, color := slackStyle(alert)
+	title := truncate(emoji+" "+alert.Title, slackMaxHeaderChars)
+
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": title, "emoji": true},
+		},
+	}
+
+	text := fmt.Sprintf("*%s*", alert.CheckName)
+	if alert.Message != "" {
+		text += "\n" + alert.Message
+	}
+	blocks = append(blocks, map[string]any{
+		"type": "section",
+		"text": map[string]any{"type": "mrkdwn", "text": truncate(text, slackMaxTextChars)},
+	})
+
+	if fields := slackFields(alert.Metadata); len(fields) > 0 {
+		blocks = append(blocks, map[string]any{
+			"type":   "section",
+			"fields": fields,
+		})
+	}
+
+	elements := []map[string]any{
+		{"type": "mrkdwn", "text": fmt.Sprintf("Priority: *%s*", alert.Priority)},
+		{"type": "mrkdwn", "text": fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>",
+			alert.Timestamp.Unix(), alert.Timestamp.Format(time.RFC1123))},
+	}
+	for _, tag := range alert.Tags {
+		if len(elements) >= slackMaxContext {
+			break
+		}
+		elements = append(elements, map[string]any{"type": "mrkdwn", "text": "`" + tag + "`"})
+	}
+	blocks = append(blocks, map[string]any{
+		"type":     "context",
+		"elements": elements,
+	})
+
+	if s.checkURL != "" {
+		link := strings.ReplaceAll(s.checkURL, "{check}", url.PathEscape(alert.CheckName))
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{
+				{
+					"type": "button",
+					"text": map[string]any{"type": "plain_text", "text": "View check"},
+					"url":  link,
+				},
+			},
+		})
+	}
+
+	return map[string]any{
+		// Fallback for notifications and clients without Block Kit
+		"text": fmt.Sprintf("%s [%s] %s: %s", emoji, alert.CheckName, alert.Title, alert.Message),
+		"attachments": []map[string]any{
+			{"color": color, "blocks": blocks},
+		},
+	}
+}
+
+// slackStyle maps an alert to an emoji and attachment color
+func slackStyle(alert check.Alert) (string, string) {
+	if alert.Recovery {
+		return ":white_check_mark:", "#2eb67d"
+	}
+	if alert.Status == check.StatusUnknown {
+		return ":grey_question:", "#9e9e9e"
+	}
+
+	switch alert.Priority {
+	case check.PriorityLow:
+		return ":information_source:", "#439fe0"
+	case check.PriorityHigh:
+		return ":rotating_light:", "#e01e5a"
+	case check.PriorityUrgent:
+		return ":fire:", "#8b0000"
+	default:
+		return ":warning:", "#ecb22e"
+	}
+}
+
+// slackFields renders metadata as section fields, sorted by key
+func slackFields(metadata map[string]string) []map[string]any {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]map[string]any, 0, min(len(keys), slackMaxFields))
+	for _, k := range keys {
+		if len(fields) >= slackMaxFields {
+			break
+		}
+		fields = append(fields, map[string]any{
+			"type": "mrkdwn",
+			"text": truncate(fmt.Sprintf("*%s*\n%s", k, metadata[k]), 2000),
+		})
+	}
+	return fields
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
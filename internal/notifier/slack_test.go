@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+)
+
+// slackRequest is what a stub Slack server received
+type slackRequest struct {
+	path    string
+	auth    string
+	payload map[string]any
+}
+
+// slackServer stubs Slack, answering every request with status and body
+func slackServer(t *testing.T, status int, header http.Header, body string) (*httptest.Server, *[]slackRequest) {
+	t.Helper()
+	var requests []slackRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := slackRequest{path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&req.payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		requests = append(requests, req)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+var slackAlert = check.Alert{
+	CheckName: "api",
+	Title:     "[CRITICAL] Down",
+	Message:   "api is not responding",
+	Priority:  check.PriorityHigh,
+	Tags:      []string{"web"},
+	Metadata:  map[string]string{"status": "CRITICAL"},
+	Timestamp: time.Unix(1700000000, 0),
+	Status:    check.StatusCritical,
+}
+
+func TestSlackWebhook(t *testing.T) {
+	srv, requests := slackServer(t, http.StatusOK, nil, "ok")
+	s := NewSlackWebhook(srv.URL+"/services/T/B/X", WithSlackCheckURL("https://status.example.com/checks/{check}"))
+
+	if err := s.Send(context.Background(), slackAlert); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.path != "/services/T/B/X" || req.auth != "" {
+		t.Errorf("request = %s with auth %q, want the webhook without a token", req.path, req.auth)
+	}
+	if _, ok := req.payload["channel"]; ok {
+		t.Error("webhook payload names a channel")
+	}
+	if text, _ := req.payload["text"].(string); !strings.Contains(text, "[api] [CRITICAL] Down: api is not responding") {
+		t.Errorf("fallback text = %q", text)
+	}
+
+	body, _ := json.Marshal(req.payload)
+	for _, want := range []string{`"color":"#e01e5a"`, `"type":"header"`, "https://status.example.com/checks/api", "`web`"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("payload missing %s: %s", want, body)
+		}
+	}
+}
+
+func TestSlackBot(t *testing.T) {
+	srv, requests := slackServer(t, http.StatusOK, nil, `{"ok": true}`)
+	s := NewSlackBot("xoxb-token", "#alerts", WithSlackAPIURL(srv.URL+"/"))
+
+	if err := s.Send(context.Background(), slackAlert); err != nil {
+		t.Fatal(err)
+	}
+	req := (*requests)[0]
+	if req.path != "/chat.postMessage" || req.auth != "Bearer xoxb-token" {
+		t.Errorf("request = %s with auth %q, want chat.postMessage with the bot token", req.path, req.auth)
+	}
+	if req.payload["channel"] != "#alerts" {
+		t.Errorf("channel = %v, want #alerts", req.payload["channel"])
+	}
+}
+
+func TestSlackBotAPIError(t *testing.T) {
+	srv, _ := slackServer(t, http.StatusOK, nil, `{"ok": false, "error": "channel_not_found"}`)
+	s := NewSlackBot("xoxb-token", "#missing", WithSlackAPIURL(srv.URL))
+
+	err := s.Send(context.Background(), slackAlert)
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("error = %v, want the API error", err)
+	}
+	if retry, _ := Retryable(err); retry {
+		t.Error("API error is retryable")
+	}
+}
+
+func TestSlackRateLimited(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(url string) *Slack
+	}{
+		{"webhook", func(url string) *Slack { return NewSlackWebhook(url) }},
+		{"bot", func(url string) *Slack { return NewSlackBot("xoxb-token", "#alerts", WithSlackAPIURL(url)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := slackServer(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, `{"ok": false, "error": "ratelimited"}`)
+
+			err := tt.new(srv.URL).Send(context.Background(), slackAlert)
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("error = %v, want a 429 StatusError", err)
+			}
+			retry, after := Retryable(err)
+			if !retry || after != 30*time.Second {
+				t.Errorf("Retryable = (%t, %s), want (true, 30s)", retry, after)
+			}
+		})
+	}
+}
+
+func TestSlackStyle(t *testing.T) {
+	tests := []struct {
+		alert check.Alert
+		color string
+	}{
+		{check.Alert{Recovery: true, Priority: check.PriorityHigh}, "#2eb67d"},
+		{check.Alert{Status: check.StatusUnknown, Priority: check.PriorityHigh}, "#9e9e9e"},
+		{check.Alert{Priority: check.PriorityLow}, "#439fe0"},
+		{check.Alert{Priority: check.PriorityNormal}, "#ecb22e"},
+		{check.Alert{Priority: check.PriorityUrgent}, "#8b0000"},
+	}
+
+	for _, tt := range tests {
+		if _, color := slackStyle(tt.alert); color != tt.color {
+			t.Errorf("slackStyle(%+v) color = %s, want %s", tt.alert, color, tt.color)
+		}
+	}
+}
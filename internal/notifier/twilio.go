@@ -89,7 +89,7 @@ func (t *Twilio) Send(ctx context.Context, alert check.Alert) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("twilio returned status %d", resp.StatusCode)
+		return statusError("twilio", resp)
 	}
 
 	return nil
@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+)
+
+// defaultWebhookTemplate is used when no body template is configured
+const defaultWebhookTemplate = `{
+  "check": {{json .CheckName}},
+  "title": {{json .Title}},
+  "message": {{json .Message}},
+  "priority": {{json .Priority.String}},
+  "status": {{json .Status.String}},
+  "previous_status": {{json .PreviousStatus.String}},
+  "tags": {{json .Tags}},
+  "metadata": {{json .Metadata}},
+  "recovery": {{json .Recovery}},
+  "repeat": {{json .Repeat}},
+  "timestamp": {{json .Timestamp}}
+}`
+
+// webhookFuncs are available to webhook body templates
+var webhookFuncs = template.FuncMap{
+	// json renders any value as a JSON literal (strings are quoted)
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// jsonEscape escapes a string for use inside an existing JSON string literal
+	"jsonEscape": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		return string(b[1 : len(b)-1]), nil
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseWebhookTemplate parses a webhook body template. The template is
+// executed with a check.Alert and may use the json, jsonEscape, join, upper
+// and lower functions. An empty body selects the default JSON payload.
+func ParseWebhookTemplate(body string) (*template.Template, error) {
+	if body == "" {
+		body = defaultWebhookTemplate
+	}
+	return template.New("webhook").Funcs(webhookFuncs).Parse(body)
+}
+
+// Webhook sends alerts to an arbitrary HTTP endpoint
+type Webhook struct {
+	url           string
+	method        string
+	headers       map[string]string
+	body          *template.Template
+	successStatus []int
+	httpClient    *http.Client
+}
+
+// WebhookOption configures the Webhook notifier
+type WebhookOption func(*Webhook)
+
+// WithWebhookMethod sets the HTTP method (defaults to POST)
+func WithWebhookMethod(method string) WebhookOption {
+	return func(w *Webhook) {
+		w.method = strings.ToUpper(method)
+	}
+}
+
+// WithWebhookHeader adds a request header
+func WithWebhookHeader(key, value string) WebhookOption {
+	return func(w *Webhook) {
+		w.headers[key] = value
+	}
+}
+
+// WithWebhookSuccessStatus sets the status codes treated as success (defaults to any 2xx)
+func WithWebhookSuccessStatus(codes ...int) WebhookOption {
+	return func(w *Webhook) {
+		w.successStatus = codes
+	}
+}
+
+// WithWebhookHTTPClient sets a custom HTTP client
+func WithWebhookHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.httpClient = client
+	}
+}
+
+// NewWebhook creates a webhook notifier that renders bodyTemplate for each alert
+func NewWebhook(url, bodyTemplate string, opts ...WebhookOption) (*Webhook, error) {
+	tmpl, err := ParseWebhookTemplate(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook template: %w", err)
+	}
+
+	w := &Webhook{
+		url:     url,
+		method:  http.MethodPost,
+		headers: make(map[string]string),
+		body:    tmpl,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w, nil
+}
+
+// Name returns the notifier name
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send renders the body template and sends it to the webhook URL
+func (w *Webhook) Send(ctx context.Context, alert check.Alert) error {
+	var body bytes.Buffer
+	if err := w.body.Execute(&body, alert); err != nil {
+		return fmt.Errorf("render template: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.method, w.url, &body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !w.isSuccess(resp.StatusCode) {
+		return statusError("webhook", resp)
+	}
+
+	return nil
+}
+
+// isSuccess reports whether a response status counts as delivered
+func (w *Webhook) isSuccess(code int) bool {
+	if len(w.successStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(w.successStatus, code)
+}
@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// Heartbeat alerts when a job that should ping periodically stops pinging,
+// or reports that it failed
+type Heartbeat struct {
+	// Pings is where the scheduler stores pings (nil fails every run)
+	Pings state.Pings
+	// Period is how often the job is expected to ping
+	Period time.Duration
+	// Grace is how late a ping may be before it is overdue
+	Grace time.Duration
+
+	// Alert fields used when the job is overdue or failed
+	Title    string
+	Priority check.Priority
+	Tags     []string
+}
+
+// Check wraps the heartbeat in a check.Check that evaluates the latest
+// pings every interval. A check that has never been pinged counts as
+// pinged when Check was called, i.e. when the process started.
+func (p *Heartbeat) Check(name string, interval time.Duration) check.Check {
+	since := time.Now()
+	return check.Check{
+		Name:      name,
+		Interval:  interval,
+		Heartbeat: true,
+		Run: func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
+			return p.evaluate(name, since, time.Now())
+		},
+	}
+}
+
+// evaluate reports whether the job is overdue or failed at now
+func (p *Heartbeat) evaluate(name string, since, now time.Time) (check.CheckResult, error) {
+	if p.Pings == nil {
+		return check.CheckResult{}, fmt.Errorf("state backend does not store pings")
+	}
+
+	hb, pinged := p.Pings.Heartbeat(name)
+	metadata := map[string]string{
+		"period": p.Period.String(),
+		"grace":  p.Grace.String(),
+	}
+	if pinged {
+		metadata["last_ping"] = hb.LastPing().Format(time.RFC3339)
+	}
+
+	if hb.Failed() {
+		return p.failure("Job Failed", fmt.Sprintf("%s reported failure at %s", name, hb.LastFail.Local().Format(time.DateTime)), metadata), nil
+	}
+
+	// Start pings don't count as check-ins: the job has to finish in time
+	last := hb.LastSuccess
+	if last.IsZero() {
+		last = since
+	}
+	if deadline := last.Add(p.Period + p.Grace); now.After(deadline) {
+		message := fmt.Sprintf("%s has not pinged since %s (expected every %s, %s grace)",
+			name, last.Local().Format(time.DateTime), p.Period, p.Grace)
+		if !pinged {
+			message = fmt.Sprintf("%s has not pinged since startup (expected every %s, %s grace)", name, p.Period, p.Grace)
+		}
+		if hb.Running() {
+			message += fmt.Sprintf("; started at %s and still running", hb.LastStart.Local().Format(time.DateTime))
+		}
+		return p.failure("Heartbeat Missed", message, metadata), nil
+	}
+
+	return check.CheckResult{Metadata: metadata}, nil
+}
+
+// failure builds an alerting result for the heartbeat, titled
+// defaultTitle unless the heartbeat has its own
+func (p *Heartbeat) failure(defaultTitle, message string, metadata map[string]string) check.CheckResult {
+	title := p.Title
+	if title == "" {
+		title = defaultTitle
+	}
+
+	return check.CheckResult{
+		ShouldAlert: true,
+		Title:       title,
+		Message:     message,
+		Priority:    p.Priority,
+		Tags:        p.Tags,
+		Metadata:    metadata,
+	}
+}
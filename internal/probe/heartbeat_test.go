@@ -0,0 +1,120 @@
+package probe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+func TestHeartbeatEvaluate(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return since.Add(d) }
+
+	tests := []struct {
+		name    string
+		pings   map[state.PingKind]time.Duration // ping times after since
+		now     time.Duration
+		title   string // empty when the job is fine
+		message string // substring of the message
+	}{
+		{name: "never pinged, within period", now: time.Hour},
+		{name: "never pinged, within grace", now: 24*time.Hour + 59*time.Minute},
+		{
+			name:    "never pinged, overdue",
+			now:     25*time.Hour + time.Second,
+			title:   "Heartbeat Missed",
+			message: "has not pinged since startup",
+		},
+		{
+			name:  "pinged on time",
+			pings: map[state.PingKind]time.Duration{state.PingSuccess: 20 * time.Hour},
+			now:   44 * time.Hour,
+		},
+		{
+			name:  "late but within grace",
+			pings: map[state.PingKind]time.Duration{state.PingSuccess: 20 * time.Hour},
+			now:   44*time.Hour + 30*time.Minute,
+		},
+		{
+			name:    "past grace",
+			pings:   map[state.PingKind]time.Duration{state.PingSuccess: 20 * time.Hour},
+			now:     45*time.Hour + time.Second,
+			title:   "Heartbeat Missed",
+			message: "expected every 24h0m0s, 1h0m0s grace",
+		},
+		{
+			name:    "started but never finished",
+			pings:   map[state.PingKind]time.Duration{state.PingSuccess: time.Hour, state.PingStart: 24 * time.Hour},
+			now:     27 * time.Hour,
+			title:   "Heartbeat Missed",
+			message: "still running",
+		},
+		{
+			name:  "running within grace",
+			pings: map[state.PingKind]time.Duration{state.PingSuccess: time.Hour, state.PingStart: 24 * time.Hour},
+			now:   25 * time.Hour,
+		},
+		{
+			name:    "failed",
+			pings:   map[state.PingKind]time.Duration{state.PingSuccess: time.Hour, state.PingFail: 2 * time.Hour},
+			now:     3 * time.Hour,
+			title:   "Job Failed",
+			message: "reported failure",
+		},
+		{
+			name:  "succeeded after failing",
+			pings: map[state.PingKind]time.Duration{state.PingFail: time.Hour, state.PingSuccess: 2 * time.Hour},
+			now:   3 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pings := state.NewMemory()
+			for kind, d := range tt.pings {
+				pings.RecordPing("backup", kind, at(d))
+			}
+			hb := &Heartbeat{Pings: pings, Period: 24 * time.Hour, Grace: time.Hour, Priority: check.PriorityHigh}
+
+			result, err := hb.evaluate("backup", since, at(tt.now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.title == "" {
+				if result.ShouldAlert {
+					t.Errorf("alerted %q: %s", result.Title, result.Message)
+				}
+				return
+			}
+			if !result.ShouldAlert || result.Title != tt.title || !strings.Contains(result.Message, tt.message) {
+				t.Errorf("result = %t %q %q, want %q containing %q", result.ShouldAlert, result.Title, result.Message, tt.title, tt.message)
+			}
+			if result.Priority != check.PriorityHigh || result.Metadata["period"] != "24h0m0s" {
+				t.Errorf("result = %s %v", result.Priority, result.Metadata)
+			}
+		})
+	}
+}
+
+func TestHeartbeatCustomTitle(t *testing.T) {
+	hb := &Heartbeat{Pings: state.NewMemory(), Period: time.Hour, Title: "Backup Missing", Tags: []string{"backup"}}
+	now := time.Now()
+
+	result, err := hb.evaluate("backup", now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Title != "Backup Missing" || len(result.Tags) != 1 {
+		t.Errorf("result = %q %v", result.Title, result.Tags)
+	}
+}
+
+func TestHeartbeatWithoutPings(t *testing.T) {
+	hb := &Heartbeat{Period: time.Hour}
+	if _, err := hb.evaluate("backup", time.Now(), time.Now()); err == nil {
+		t.Error("evaluated without a ping store")
+	}
+}
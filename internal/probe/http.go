@@ -0,0 +1,211 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	maxBodyBytes       = 1 << 20 // Only the first 1MB of a response is inspected
+)
+
+// HTTP probes a URL and alerts when any of its assertions fail
+type HTTP struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Body    string
+
+	// ExpectedStatus lists acceptable status codes (any 2xx if empty)
+	ExpectedStatus []int
+	// MaxLatency fails the probe if the response takes longer (0 disables)
+	MaxLatency time.Duration
+	// BodyContains requires the response body to contain this substring
+	BodyContains string
+	// BodyRegex requires the response body to match this pattern
+	BodyRegex *regexp.Regexp
+	// JSONPath is a dotted path into a JSON response body (e.g. "status.indicator" or "items.0.id")
+	JSONPath string
+	// JSONValue is the expected value at JSONPath (empty only requires the path to exist)
+	JSONValue string
+
+	// Alert fields used when the probe fails
+	Title    string
+	Priority check.Priority
+	Tags     []string
+}
+
+// Check wraps the probe in a check.Check
+func (p *HTTP) Check(name string, interval time.Duration) check.Check {
+	return check.Check{
+		Name:     name,
+		Interval: interval,
+		Run:      p.Run,
+	}
+}
+
+// Run performs the request and evaluates every assertion.
+// Network failures are reported as alerts rather than check errors, so an
+// unreachable endpoint alerts instead of backing off silently.
+func (p *HTTP) Run(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
+	method := p.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if p.Body != "" {
+		body = strings.NewReader(p.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.URL, body)
+	if err != nil {
+		return check.CheckResult{}, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: defaultHTTPTimeout}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return p.failure(fmt.Sprintf("%s is not responding: %v", p.URL, err), nil), nil
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	latency := time.Since(start)
+	if err != nil {
+		return p.failure(fmt.Sprintf("%s: read body: %v", p.URL, err), nil), nil
+	}
+
+	metadata := map[string]string{
+		"status":  strconv.Itoa(resp.StatusCode),
+		"latency": latency.Round(time.Millisecond).String(),
+	}
+
+	var problems []string
+	if !p.statusOK(resp.StatusCode) {
+		problems = append(problems, fmt.Sprintf("unexpected status %d", resp.StatusCode))
+	}
+	if p.MaxLatency > 0 && latency > p.MaxLatency {
+		problems = append(problems, fmt.Sprintf("response slower than %s", p.MaxLatency))
+	}
+	if p.BodyContains != "" && !strings.Contains(string(respBody), p.BodyContains) {
+		problems = append(problems, fmt.Sprintf("body does not contain %q", p.BodyContains))
+	}
+	if p.BodyRegex != nil && !p.BodyRegex.Match(respBody) {
+		problems = append(problems, fmt.Sprintf("body does not match /%s/", p.BodyRegex))
+	}
+	if p.JSONPath != "" {
+		if problem := p.checkJSON(respBody); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return p.failure(fmt.Sprintf("%s: %s", p.URL, strings.Join(problems, "; ")), metadata), nil
+	}
+
+	return check.CheckResult{ShouldAlert: false, Metadata: metadata}, nil
+}
+
+// statusOK reports whether code is an acceptable response status
+func (p *HTTP) statusOK(code int) bool {
+	if len(p.ExpectedStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(p.ExpectedStatus, code)
+}
+
+// checkJSON evaluates the JSON path assertion, returning a problem description or ""
+func (p *HTTP) checkJSON(body []byte) string {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "body is not valid JSON"
+	}
+
+	value, ok := lookupJSONPath(doc, p.JSONPath)
+	if !ok {
+		return fmt.Sprintf("JSON path %q not found", p.JSONPath)
+	}
+
+	if p.JSONValue != "" && jsonString(value) != p.JSONValue {
+		return fmt.Sprintf("JSON path %q is %s, expected %s", p.JSONPath, jsonString(value), p.JSONValue)
+	}
+
+	return ""
+}
+
+// failure builds an alerting result for the probe
+func (p *HTTP) failure(message string, metadata map[string]string) check.CheckResult {
+	title := p.Title
+	if title == "" {
+		title = "HTTP Check Failed"
+	}
+
+	return check.CheckResult{
+		ShouldAlert: true,
+		Title:       title,
+		Message:     message,
+		Priority:    p.Priority,
+		Tags:        p.Tags,
+		Metadata:    metadata,
+	}
+}
+
+// lookupJSONPath walks a decoded JSON document along a dotted path.
+// Numeric segments index into arrays.
+func lookupJSONPath(doc any, path string) (any, bool) {
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonString formats a decoded JSON value for comparison against a config string
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// ErrNotHeartbeat is returned when pinging a check that doesn't take pings
+var ErrNotHeartbeat = errors.New("not a heartbeat check")
+
+// Ping records a ping for a heartbeat check in the state backend. A fail
+// ping, or a success ping while the check is alerting, runs the check right
+// away so the change is reported without waiting for its next run.
+func (s *Scheduler) Ping(name string, kind state.PingKind) error {
+	s.mu.Lock()
+	c, ok := s.lookup(name)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCheck, name)
+	}
+	if !c.Heartbeat {
+		return fmt.Errorf("%w: %s", ErrNotHeartbeat, name)
+	}
+
+	pings, ok := s.state.(state.Pings)
+	if !ok {
+		return fmt.Errorf("state backend does not store pings")
+	}
+	if err := pings.RecordPing(name, kind, time.Now()); err != nil {
+		return err
+	}
+	s.logger.Printf("[%s] %s ping received", name, kind)
+
+	_, open := s.state.Incident(name)
+	if kind == state.PingFail || (kind == state.PingSuccess && open) {
+		return s.Trigger(name)
+	}
+	return nil
+}
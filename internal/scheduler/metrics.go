@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/murr/check-and-ping/internal/metrics"
+)
+
+// checkDurationBuckets cover quick HTTP checks through slow Claude analyses
+var checkDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// schedulerMetrics are the scheduler's instruments; with a nil registry
+// they record nothing
+type schedulerMetrics struct {
+	runs       *metrics.Counter
+	duration   *metrics.Histogram
+	backoff    *metrics.Gauge
+	suppressed *metrics.Counter
+}
+
+func newSchedulerMetrics(reg *metrics.Registry) *schedulerMetrics {
+	return &schedulerMetrics{
+		runs: reg.Counter("checkandping_check_runs_total",
+			"Check runs by outcome (ok, warning, critical, unknown, error or busy).", "check", "outcome"),
+		duration: reg.Histogram("checkandping_check_duration_seconds",
+			"How long check runs took.", checkDurationBuckets, "check", "outcome"),
+		backoff: reg.Gauge("checkandping_check_backoff_multiplier",
+			"Current multiplier on the check's interval after errors.", "check"),
+		suppressed: reg.Counter("checkandping_alerts_suppressed_total",
+			"Notifications not sent, by reason (duplicate, acknowledged, silenced or flapping).", "check", "reason"),
+	}
+}
+
+// WithMetrics records check runs, backoff and suppressed notifications in reg
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(s *Scheduler) {
+		s.metrics = newSchedulerMetrics(reg)
+	}
+}
+
+// observeRun records a finished run
+func (m *schedulerMetrics) observeRun(name, outcome string, d time.Duration) {
+	m.runs.Inc(name, outcome)
+	m.duration.Observe(d.Seconds(), name, outcome)
+}
+
+// forget drops a removed check's series, so /metrics stops reporting them
+func (m *schedulerMetrics) forget(name string) {
+	m.runs.DeleteMatching("check", name)
+	m.duration.DeleteMatching("check", name)
+	m.backoff.DeleteMatching("check", name)
+	m.suppressed.DeleteMatching("check", name)
+}
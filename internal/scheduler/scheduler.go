@@ -2,62 +2,173 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/murr/check-and-ping/internal/check"
 	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/cron"
 	"github.com/murr/check-and-ping/internal/notifier"
 	"github.com/murr/check-and-ping/internal/state"
 )
 
 const (
-	maxBackoffMultiplier = 32  // Max 32x the base interval
+	maxBackoffMultiplier = 32 // Max 32x the base interval
 	maxBackoffDuration   = time.Hour
+	defaultCheckTimeout  = 5 * time.Minute
+	busyRetryDelay       = time.Minute // retry after Claude was too busy to run the check
 )
 
-// Scheduler runs checks at configured intervals
+// Scheduler runs checks at configured intervals or cron schedules
 type Scheduler struct {
 	checks   []check.Check
-	claude   *claude.Client
+	claude   claude.Analyzer
 	notifier notifier.Notifier
 	state    state.State
 	logger   *log.Logger
 
+	// renotify is the reminder policy for checks that don't set their own
+	renotify check.RenotifyPolicy
+	// timeout bounds runs of checks that don't set their own
+	timeout time.Duration
+
+	metrics *schedulerMetrics
+
+	// mu guards checks, runtimes and notifier, which the status API and
+	// config reloads use while checks run
+	mu       sync.Mutex
+	runtimes map[string]*checkRuntime
+
+	// ctx is set by Start; checks registered afterwards start right away
+	ctx    context.Context
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
 }
 
+// Option configures the Scheduler
+type Option func(*Scheduler)
+
+// WithRenotify sets the default reminder policy for checks without their own
+func WithRenotify(policy check.RenotifyPolicy) Option {
+	return func(s *Scheduler) {
+		s.renotify = policy
+	}
+}
+
+// WithDefaultTimeout sets the run timeout for checks without their own
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.timeout = d
+	}
+}
+
 // New creates a new scheduler
-func New(claude *claude.Client, notifier notifier.Notifier, state state.State, logger *log.Logger) *Scheduler {
+func New(claude claude.Analyzer, notifier notifier.Notifier, state state.State, logger *log.Logger, opts ...Option) *Scheduler {
 	if logger == nil {
 		logger = log.Default()
 	}
 
-	return &Scheduler{
+	s := &Scheduler{
 		claude:   claude,
 		notifier: notifier,
 		state:    state,
 		logger:   logger,
+		timeout:  defaultCheckTimeout,
+		runtimes: make(map[string]*checkRuntime),
+		metrics:  newSchedulerMetrics(nil),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
-// Register adds a check to the scheduler
-func (s *Scheduler) Register(c check.Check) {
+// ErrDuplicateCheck is returned when registering a name that is already taken
+var ErrDuplicateCheck = errors.New("check already registered")
+
+// Register adds a check to the scheduler. Check names must be unique.
+// A check registered after Start begins running immediately.
+func (s *Scheduler) Register(c check.Check) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.runtimes[c.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateCheck, c.Name)
+	}
+	rt := newCheckRuntime()
 	s.checks = append(s.checks, c)
+	s.runtimes[c.Name] = rt
+	if s.ctx != nil {
+		s.start(c, rt)
+	}
+	return nil
+}
+
+// Unregister removes a check and its metrics. If the scheduler is running,
+// the check's goroutine is stopped, canceling any run in progress, and
+// waited for.
+func (s *Scheduler) Unregister(name string) error {
+	s.mu.Lock()
+	i := slices.IndexFunc(s.checks, func(c check.Check) bool { return c.Name == name })
+	if i < 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownCheck, name)
+	}
+	s.checks = slices.Delete(s.checks, i, i+1)
+	rt := s.runtimes[name]
+	delete(s.runtimes, name)
+	s.mu.Unlock()
+
+	if rt.stop != nil {
+		rt.stop()
+		<-rt.done
+	}
+	// After the goroutine is gone, so a final run can't record them again
+	s.metrics.forget(name)
+	s.logger.Printf("[%s] unregistered", name)
+	return nil
+}
+
+// SetNotifier replaces the notifier used for alerts sent from now on
+func (s *Scheduler) SetNotifier(n notifier.Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = n
 }
 
 // Start begins running all registered checks
 func (s *Scheduler) Start(ctx context.Context) {
 	ctx, s.cancel = context.WithCancel(ctx)
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
 	for _, c := range s.checks {
-		s.wg.Add(1)
-		go s.runCheck(ctx, c)
+		s.start(c, s.runtimes[c.Name])
 	}
 }
 
+// start runs a check in its own goroutine until the scheduler stops or the
+// check is unregistered. The caller must hold s.mu.
+func (s *Scheduler) start(c check.Check, rt *checkRuntime) {
+	ctx, stop := context.WithCancel(s.ctx)
+	rt.stop = stop
+	rt.done = make(chan struct{})
+
+	s.wg.Add(1)
+	go func() {
+		defer close(rt.done)
+		s.runCheck(ctx, c, rt)
+	}()
+}
+
 // Stop gracefully shuts down the scheduler
 func (s *Scheduler) Stop() {
 	if s.cancel != nil {
@@ -66,74 +177,570 @@ func (s *Scheduler) Stop() {
 	s.wg.Wait()
 }
 
-// runCheck runs a single check on its interval with exponential backoff
-func (s *Scheduler) runCheck(ctx context.Context, c check.Check) {
+// RunOnce executes every registered check a single time and returns the
+// number of checks whose result called for an alert
+func (s *Scheduler) RunOnce(ctx context.Context) int {
+	alerts := 0
+	s.mu.Lock()
+	checks := append([]check.Check(nil), s.checks...)
+	s.mu.Unlock()
+
+	for _, c := range checks {
+		if alerted, _ := s.executeCheck(ctx, c, s.runtime(c.Name)); alerted {
+			alerts++
+		}
+	}
+	return alerts
+}
+
+// runCheck runs a single check on its interval or cron schedule with exponential backoff
+func (s *Scheduler) runCheck(ctx context.Context, c check.Check, rt *checkRuntime) {
 	defer s.wg.Done()
 
-	backoffMultiplier := 1
-	consecutiveFailures := 0
+	var schedule *cron.Schedule
+	if c.Schedule != "" {
+		var err error
+		if schedule, err = cron.Parse(c.Schedule); err != nil {
+			s.logger.Printf("[%s] invalid schedule, check disabled: %v", c.Name, err)
+			return
+		}
+	}
+
+	retry := false
 
-	// Run immediately on start
-	s.executeCheck(ctx, c, &backoffMultiplier, &consecutiveFailures)
+	// Interval checks run immediately on start; cron checks wait for their first activation
+	if schedule == nil && !s.paused(rt) {
+		_, retry = s.executeCheck(ctx, c, rt)
+	}
 
 	for {
-		// Calculate next interval with backoff
-		interval := c.Interval * time.Duration(backoffMultiplier)
-		if interval > maxBackoffDuration {
-			interval = maxBackoffDuration
+		interval, ok := nextDelay(c, schedule, rt.backoffMultiplier, time.Now())
+		if !ok {
+			s.logger.Printf("[%s] schedule %q never fires, stopping", c.Name, c.Schedule)
+			return
 		}
+		if retry && interval > busyRetryDelay {
+			interval = busyRetryDelay
+		}
+		s.mu.Lock()
+		rt.nextRun = time.Now().Add(interval)
+		s.mu.Unlock()
 
 		select {
 		case <-ctx.Done():
 			return
+		case <-rt.trigger:
+			// Manual runs ignore pausing
+			s.logger.Printf("[%s] triggered", c.Name)
+			_, retry = s.executeCheck(ctx, c, rt)
 		case <-time.After(interval):
-			s.executeCheck(ctx, c, &backoffMultiplier, &consecutiveFailures)
+			if s.paused(rt) {
+				s.logger.Printf("[%s] paused, skipping run", c.Name)
+				retry = false
+				continue
+			}
+			_, retry = s.executeCheck(ctx, c, rt)
+		}
+	}
+}
+
+// nextDelay returns how long to wait before the next run of a check.
+// Interval checks wait the interval times the backoff multiplier, capped at
+// maxBackoffDuration. Cron checks wait for an activation: while backing off
+// they only run on every backoffMultiplier-th activation, but never skip one
+// that falls within maxBackoffDuration of the next. The bool is false when
+// the schedule never fires again.
+func nextDelay(c check.Check, schedule *cron.Schedule, backoffMultiplier int, now time.Time) (time.Duration, bool) {
+	if schedule == nil {
+		interval := c.Interval * time.Duration(backoffMultiplier)
+		if interval > maxBackoffDuration {
+			interval = maxBackoffDuration
+		}
+		return interval, true
+	}
+
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return 0, false
+	}
+	for i := 1; i < backoffMultiplier; i++ {
+		later := schedule.Next(next)
+		if later.IsZero() || later.Sub(now) > maxBackoffDuration {
+			break
 		}
+		next = later
 	}
+
+	return next.Sub(now), true
 }
 
-func (s *Scheduler) executeCheck(ctx context.Context, c check.Check, backoffMultiplier *int, consecutiveFailures *int) {
+// checkRuntime is what the scheduler remembers about a check between runs.
+// Only the check's own goroutine writes it, holding Scheduler.mu so the
+// status API can read it.
+type checkRuntime struct {
+	backoffMultiplier   int
+	consecutiveFailures int // runs that returned an error, for backoff
+	consecutiveProblems int // runs with a non-OK status, for FailureThreshold
+	consecutiveOK       int // runs with an OK status, for SuccessThreshold
+
+	lastStatus check.Status
+	changes    []time.Time // when the status changed, for flap detection
+	flapping   bool
+
+	// Reported by the status API
+	running      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	lastErrorAt  time.Time
+	nextRun      time.Time
+
+	paused  bool          // set by Pause and Resume
+	trigger chan struct{} // a send asks the check's goroutine to run it now
+
+	stop context.CancelFunc // ends the check's goroutine, nil until started
+	done chan struct{}      // closed when the check's goroutine returns
+}
+
+func newCheckRuntime() *checkRuntime {
+	return &checkRuntime{backoffMultiplier: 1, trigger: make(chan struct{}, 1)}
+}
+
+// observe records a run's status and reports whether the check started or
+// stopped flapping
+func (rt *checkRuntime) observe(status check.Status, flap check.FlapPolicy, now time.Time) (started, stopped bool) {
+	if status == check.StatusOK {
+		rt.consecutiveOK++
+		rt.consecutiveProblems = 0
+	} else {
+		rt.consecutiveProblems++
+		rt.consecutiveOK = 0
+	}
+
+	if status != rt.lastStatus {
+		rt.changes = append(rt.changes, now)
+		rt.lastStatus = status
+	}
+	if !flap.Enabled() {
+		rt.changes = nil
+		return false, false
+	}
+
+	cutoff := now.Add(-flap.Window)
+	i := 0
+	for i < len(rt.changes) && rt.changes[i].Before(cutoff) {
+		i++
+	}
+	rt.changes = rt.changes[i:]
+
+	was := rt.flapping
+	rt.flapping = flap.Flapping(len(rt.changes), was)
+	return rt.flapping && !was, was && !rt.flapping
+}
+
+// executeCheck runs a check once and reports whether it ended in a non-OK
+// status, and whether it should be retried soon because Claude was busy.
+// Notifications are sent when the status changes (subject to the check's
+// thresholds and flap detection), or as reminders while it stays the same.
+// Check errors back off, and put the check in the UNKNOWN status if it
+// has AlertOnError set.
+func (s *Scheduler) executeCheck(ctx context.Context, c check.Check, rt *checkRuntime) (alerted, retry bool) {
 	s.logger.Printf("[%s] running check", c.Name)
 
-	result, err := c.Run(ctx, s.claude)
+	s.mu.Lock()
+	rt.running = true
+	s.mu.Unlock()
+
+	start := time.Now()
+	result, err := s.runWithTimeout(ctx, c)
+
+	duration := time.Since(start)
+	s.mu.Lock()
+	rt.running = false
+	rt.lastRun = start
+	rt.lastDuration = duration
 	if err != nil {
-		*consecutiveFailures++
-		*backoffMultiplier = min(1<<*consecutiveFailures, maxBackoffMultiplier)
-		s.logger.Printf("[%s] check error (backoff %dx): %v", c.Name, *backoffMultiplier, err)
-		return
+		rt.lastError = err.Error()
+		rt.lastErrorAt = start
+	}
+	s.mu.Unlock()
+
+	if errors.Is(err, claude.ErrRateLimited) {
+		// Not a check failure: leave backoff and history alone and try again soon
+		s.logger.Printf("[%s] Claude busy, retrying later: %v", c.Name, err)
+		s.metrics.observeRun(c.Name, "busy", duration)
+		return false, true
+	}
+	s.recordRun(c.Name, start, result, err)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down, not a check failure
+		return false, false
+	}
+
+	s.mu.Lock()
+	if err != nil {
+		rt.consecutiveFailures++
+		rt.backoffMultiplier = min(1<<rt.consecutiveFailures, maxBackoffMultiplier)
+	} else {
+		// Reset backoff on success
+		rt.consecutiveFailures = 0
+		rt.backoffMultiplier = 1
 	}
+	backoff := rt.backoffMultiplier
+	s.mu.Unlock()
+	s.metrics.backoff.Set(float64(backoff), c.Name)
 
-	// Reset backoff on success
-	*consecutiveFailures = 0
-	*backoffMultiplier = 1
+	outcome := ""
+	if err != nil {
+		outcome = "error"
+		s.logger.Printf("[%s] check error (backoff %dx): %v", c.Name, backoff, err)
+		if !c.AlertOnError {
+			// Errors only back off, leaving the status as it was
+			s.metrics.observeRun(c.Name, outcome, duration)
+			return false, false
+		}
+		result = check.ErrorResult(err)
+	}
+
+	s.mu.Lock()
+	status := result.Level()
+	startedFlapping, stoppedFlapping := rt.observe(status, c.Flap, time.Now())
+	s.mu.Unlock()
+
+	if outcome == "" {
+		outcome = strings.ToLower(status.String())
+	}
+	s.metrics.observeRun(c.Name, outcome, duration)
 
-	if !result.ShouldAlert {
-		s.logger.Printf("[%s] no alert needed", c.Name)
-		// Clear state when condition clears
-		if err := s.state.Clear(c.Name); err != nil {
-			s.logger.Printf("[%s] failed to clear state: %v", c.Name, err)
+	incident, open := s.state.Incident(c.Name)
+	previous := check.StatusOK
+	if open {
+		// Incidents recorded before statuses existed count as CRITICAL
+		previous, _ = check.ParseStatus(incident.Status)
+		if incident.Status == "" {
+			previous = check.StatusCritical
 		}
-		return
 	}
 
-	// Check if we should send this alert (avoid duplicates)
+	switch {
+	case startedFlapping:
+		if alert := flapAlert(c, result, true, len(rt.changes), incident, open); !s.silenced(alert) {
+			s.sendFlapAlert(ctx, alert)
+		}
+		return status != check.StatusOK, false
+	case rt.flapping:
+		s.logger.Printf("[%s] flapping (%s), notification suppressed", c.Name, status)
+		s.metrics.suppressed.Inc(c.Name, "flapping")
+		return status != check.StatusOK, false
+	case stoppedFlapping:
+		// Report where the check settled and make that the alerted state
+		alert := flapAlert(c, result, false, len(rt.changes), incident, open)
+		silenced := s.silenced(alert)
+		if silenced || s.sendFlapAlert(ctx, alert) {
+			s.syncState(c.Name, result, status, alert.Priority, !silenced)
+		}
+		return status != check.StatusOK, false
+	}
+
+	if status == check.StatusOK {
+		if !open {
+			s.logger.Printf("[%s] no alert needed", c.Name)
+			return false, false
+		}
+		if threshold := max(c.SuccessThreshold, 1); rt.consecutiveOK < threshold {
+			s.logger.Printf("[%s] OK (%d/%d successes before resolving)", c.Name, rt.consecutiveOK, threshold)
+			return false, false
+		}
+		s.resolve(ctx, c, result, incident, previous)
+		return false, false
+	}
+
+	if !open {
+		if threshold := max(c.FailureThreshold, 1); rt.consecutiveProblems < threshold {
+			s.logger.Printf("[%s] %s (%d/%d failures before alerting)", c.Name, status, rt.consecutiveProblems, threshold)
+			return true, false
+		}
+	}
+
+	// Only notify on a status change or a new title or message (e.g. an
+	// updated price), unless a reminder is due
+	policy := s.renotifyPolicy(c)
 	resultHash := state.Hash(result.Title, result.Message)
-	if !s.state.ShouldAlert(c.Name, resultHash) {
-		s.logger.Printf("[%s] duplicate alert suppressed", c.Name)
-		return
+	repeat := 0
+	if open && previous == status {
+		if incident.Acknowledged() {
+			s.logger.Printf("[%s] still %s, acknowledged", c.Name, status)
+			s.metrics.suppressed.Inc(c.Name, "acknowledged")
+			return true, false
+		}
+		updated := err == nil && incident.Hash != resultHash
+		if !updated && !policy.Due(incident.AlertedAt, incident.Count, time.Now()) {
+			s.logger.Printf("[%s] still %s, duplicate alert suppressed", c.Name, status)
+			s.metrics.suppressed.Inc(c.Name, "duplicate")
+			return true, false
+		}
+		if !updated {
+			repeat = incident.Count
+		}
 	}
 
 	// Send alert
-	alert := check.NewAlertFromResult(c.Name, result)
-	if err := s.notifier.Send(ctx, alert); err != nil {
+	alert := check.NewAlertFromResult(c.Name, result, previous)
+	if repeat > 0 {
+		alert.Repeat = repeat
+		alert.Title = "Reminder: " + alert.Title
+		// Route the reminder like the alert it repeats
+		alert.Priority = alertedPriority(incident, alert.Priority)
+		if policy.Escalate {
+			alert.Escalate(repeat)
+		}
+	}
+	if s.silenced(alert) {
+		// Not marked as alerted, so it is sent if still failing once the silence ends
+		return true, false
+	}
+	if err := s.send(ctx, alert); err != nil {
 		s.logger.Printf("[%s] notification error: %v", c.Name, err)
-		return
+		return true, false
 	}
 
 	// Mark as alerted
-	if err := s.state.MarkAlerted(c.Name, resultHash); err != nil {
+	if err := s.state.MarkAlerted(c.Name, resultHash, status.String(), alert.RoutePriority().String()); err != nil {
 		s.logger.Printf("[%s] failed to mark alerted: %v", c.Name, err)
 	}
 
-	s.logger.Printf("[%s] alert sent: %s", c.Name, result.Title)
+	s.logger.Printf("[%s] alert sent (%s -> %s): %s", c.Name, previous, status, alert.Title)
+	return true, false
+}
+
+// send delivers an alert with the current notifier. When it fans out to
+// several channels, those that delivered the alert on an earlier, partly
+// failed attempt are skipped, so only the failed ones are retried. Once
+// every channel has delivered it, the check's per-channel state is reset.
+func (s *Scheduler) send(ctx context.Context, alert check.Alert) error {
+	s.mu.Lock()
+	n := s.notifier
+	s.mu.Unlock()
+
+	fanout, ok := n.(notifier.Fanout)
+	if !ok {
+		return n.Send(ctx, alert)
+	}
+
+	key := deliveryKey(alert)
+	delivered := s.state.DeliveredTo(alert.CheckName, key)
+	if len(delivered) > 0 {
+		s.logger.Printf("[%s] already delivered by %s, retrying the others", alert.CheckName, strings.Join(delivered, ", "))
+	}
+
+	err := fanout.SendExcept(ctx, alert, delivered)
+	var multiErr *notifier.MultiError
+	if errors.As(err, &multiErr) {
+		for _, name := range multiErr.Succeeded {
+			if err := s.state.MarkDelivered(alert.CheckName, name, key); err != nil {
+				s.logger.Printf("[%s] failed to record delivery by %s: %v", alert.CheckName, name, err)
+			}
+		}
+		return err
+	}
+	if err == nil {
+		if err := s.state.ResetDelivered(alert.CheckName); err != nil {
+			s.logger.Printf("[%s] failed to reset deliveries: %v", alert.CheckName, err)
+		}
+	}
+	return err
+}
+
+// deliveryKey identifies an alert across retries of a partly failed send.
+// Reminders and recoveries differ from the alert they follow.
+func deliveryKey(alert check.Alert) string {
+	return fmt.Sprintf("%s>%s repeat=%d recovery=%t flapping=%t",
+		alert.PreviousStatus, alert.Status, alert.Repeat, alert.Recovery, alert.Flapping)
+}
+
+// flapAlert creates the notification that a check started or stopped
+// flapping. While an incident is open it is sent at the priority the
+// incident was alerted at, so it is routed like the alert it follows.
+func flapAlert(c check.Check, result check.CheckResult, started bool, changes int, incident state.Incident, open bool) check.Alert {
+	alert := check.NewFlapAlert(c.Name, result, started, changes, c.Flap.Window)
+	if open {
+		alert.Priority = alertedPriority(incident, alert.Priority)
+	}
+	return alert
+}
+
+// alertedPriority returns the priority an open incident was alerted at,
+// or fallback for incidents recorded before priorities were stored
+func alertedPriority(incident state.Incident, fallback check.Priority) check.Priority {
+	if incident.Priority == "" {
+		return fallback
+	}
+	priority, err := check.ParsePriority(incident.Priority)
+	if err != nil {
+		return fallback
+	}
+	return priority
+}
+
+// sendFlapAlert sends a flapping notification and reports whether it was sent
+func (s *Scheduler) sendFlapAlert(ctx context.Context, alert check.Alert) bool {
+	if err := s.send(ctx, alert); err != nil {
+		s.logger.Printf("[%s] flapping notification error: %v", alert.CheckName, err)
+		return false
+	}
+	s.logger.Printf("[%s] flapping notification sent: %s", alert.CheckName, alert.Title)
+	return true
+}
+
+// silenced reports whether an active silence mutes the alert
+func (s *Scheduler) silenced(alert check.Alert) bool {
+	silences, ok := s.state.(state.Silences)
+	if !ok {
+		return false
+	}
+
+	silence, ok := silences.Silenced(alert.CheckName, alert.Tags, time.Now())
+	if !ok {
+		return false
+	}
+
+	s.logger.Printf("[%s] notification silenced by silence %d until %s: %s",
+		alert.CheckName, silence.ID, silence.ExpiresAt.Local().Format(time.DateTime), alert.Title)
+	s.metrics.suppressed.Inc(alert.CheckName, "silenced")
+	return true
+}
+
+// syncState records status, routed at priority, as the check's alerted
+// state without notifying. notified reports whether a notification about
+// the new status went out (see clear).
+func (s *Scheduler) syncState(name string, result check.CheckResult, status check.Status, priority check.Priority, notified bool) {
+	var err error
+	if status == check.StatusOK {
+		err = s.clear(name, notified)
+	} else {
+		err = s.state.MarkAlerted(name, state.Hash(result.Title, result.Message), status.String(), priority.String())
+	}
+	if err != nil {
+		s.logger.Printf("[%s] failed to update state: %v", name, err)
+	}
+}
+
+// renotifyPolicy returns the check's reminder policy, or the scheduler default
+func (s *Scheduler) renotifyPolicy(c check.Check) check.RenotifyPolicy {
+	if c.Renotify != nil {
+		return *c.Renotify
+	}
+	return s.renotify
+}
+
+// runWithTimeout runs a check bounded by its timeout. Panics are recovered
+// and returned as errors. A check that ignores its context is abandoned when
+// the timeout expires so it can't block its schedule.
+func (s *Scheduler) runWithTimeout(ctx context.Context, c check.Check) (check.CheckResult, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = s.timeout
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result check.CheckResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Printf("[%s] check panicked: %v\n%s", c.Name, r, debug.Stack())
+				done <- outcome{err: fmt.Errorf("check panicked: %v", r)}
+			}
+		}()
+
+		result, err := c.Run(runCtx, s.claude)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-runCtx.Done():
+		// A check that finished right at the deadline keeps its own result,
+		// e.g. ErrRateLimited, which is retried instead of backing off
+		select {
+		case o := <-done:
+			return o.result, o.err
+		default:
+		}
+		if ctx.Err() != nil {
+			return check.CheckResult{}, ctx.Err()
+		}
+		return check.CheckResult{}, fmt.Errorf("check timed out after %s", timeout)
+	}
+}
+
+// resolve clears the open incident when a check returns to OK, first
+// sending a recovery alert. If the recovery alert fails the state is kept
+// so it is retried on the next run.
+func (s *Scheduler) resolve(ctx context.Context, c check.Check, result check.CheckResult, incident state.Incident, previous check.Status) {
+	priority := alertedPriority(incident, result.Priority)
+	notified := false
+	if alert := check.NewRecoveryAlert(c.Name, result, previous, priority, time.Since(incident.OpenedAt)); !c.DisableRecovery && !s.silenced(alert) {
+		if err := s.send(ctx, alert); err != nil {
+			s.logger.Printf("[%s] recovery notification error: %v", c.Name, err)
+			return
+		}
+		notified = true
+		s.logger.Printf("[%s] recovery sent (%s -> OK): %s", c.Name, previous, alert.Title)
+	}
+
+	if err := s.clear(c.Name, notified); err != nil {
+		s.logger.Printf("[%s] failed to clear state: %v", c.Name, err)
+	}
+}
+
+// clear closes a check's incident. Unless a notification just went out
+// that supersedes them (see notifier.Outbox.Cancel), notifications still
+// queued for retry are dropped too, so a stale alert isn't delivered after
+// the condition cleared.
+func (s *Scheduler) clear(name string, notified bool) error {
+	if err := s.state.Clear(name); err != nil {
+		return err
+	}
+	if outbox, ok := s.state.(state.Outbox); ok && !notified {
+		n, err := outbox.CancelDeliveries(name, "")
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			s.logger.Printf("[%s] dropped %d queued notification(s) for the cleared alert", name, n)
+		}
+	}
+	return nil
+}
+
+// recordRun logs the run to the state backend if it keeps history
+func (s *Scheduler) recordRun(name string, start time.Time, result check.CheckResult, err error) {
+	history, ok := s.state.(state.History)
+	if !ok {
+		return
+	}
+
+	run := state.RunRecord{
+		CheckName: name,
+		StartedAt: start,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		run.Error = err.Error()
+	} else if result.Level() != check.StatusOK {
+		run.ShouldAlert = true
+		run.ResultHash = state.Hash(result.Title, result.Message)
+	}
+
+	if err := history.RecordRun(run); err != nil {
+		s.logger.Printf("[%s] failed to record run: %v", name, err)
+	}
 }
@@ -0,0 +1,478 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/metrics"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// recorder is a notifier that keeps every alert it is sent
+type recorder struct {
+	mu     sync.Mutex
+	alerts []check.Alert
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Send(ctx context.Context, alert check.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+// take returns the alerts sent since the last call
+func (r *recorder) take() []check.Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alerts := r.alerts
+	r.alerts = nil
+	return alerts
+}
+
+var (
+	ok       = check.CheckResult{Title: "Fine"}
+	warning  = check.CheckResult{Status: check.StatusWarning, Title: "Slow", Priority: check.PriorityLow}
+	critical = check.CheckResult{Status: check.StatusCritical, Title: "Down", Priority: check.PriorityHigh}
+)
+
+// harness runs one check through executeCheck with scripted results
+type harness struct {
+	t     *testing.T
+	s     *Scheduler
+	n     *recorder
+	c     check.Check
+	rt    *checkRuntime
+	next  check.CheckResult
+	err   error
+	state *state.Memory
+}
+
+func newHarness(t *testing.T, c check.Check, opts ...Option) *harness {
+	h := &harness{t: t, n: &recorder{}, state: state.NewMemory()}
+	h.s = New(nil, h.n, h.state, log.New(io.Discard, "", 0), opts...)
+	c.Name = "test"
+	c.Interval = time.Minute
+	c.Run = func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
+		return h.next, h.err
+	}
+	h.c = c
+	h.rt = newCheckRuntime()
+	return h
+}
+
+// run executes the check once with the given result and returns the alerts it sent
+func (h *harness) run(result check.CheckResult) []check.Alert {
+	h.t.Helper()
+	h.next, h.err = result, nil
+	h.s.executeCheck(context.Background(), h.c, h.rt)
+	return h.n.take()
+}
+
+// runError executes the check once failing with err
+func (h *harness) runError(err error) []check.Alert {
+	h.t.Helper()
+	h.next, h.err = check.CheckResult{}, err
+	h.s.executeCheck(context.Background(), h.c, h.rt)
+	return h.n.take()
+}
+
+// expectOne fails unless exactly one alert was sent and returns it
+func expectOne(t *testing.T, alerts []check.Alert) check.Alert {
+	t.Helper()
+	if len(alerts) != 1 {
+		t.Fatalf("sent %d alerts, want 1: %+v", len(alerts), alerts)
+	}
+	return alerts[0]
+}
+
+func expectNone(t *testing.T, alerts []check.Alert) {
+	t.Helper()
+	if len(alerts) != 0 {
+		t.Fatalf("sent %d alerts, want none: %+v", len(alerts), alerts)
+	}
+}
+
+func TestExecuteCheckTransitions(t *testing.T) {
+	h := newHarness(t, check.Check{})
+
+	expectNone(t, h.run(ok))
+
+	alert := expectOne(t, h.run(warning))
+	if alert.Title != "[WARNING] Slow" || alert.Status != check.StatusWarning || alert.PreviousStatus != check.StatusOK {
+		t.Errorf("warning alert = %q %s <- %s", alert.Title, alert.Status, alert.PreviousStatus)
+	}
+	if alert.Priority != check.PriorityLow {
+		t.Errorf("warning priority = %s, want the declared low", alert.Priority)
+	}
+
+	expectNone(t, h.run(warning))
+
+	alert = expectOne(t, h.run(critical))
+	if alert.Title != "[CRITICAL] Down" || alert.PreviousStatus != check.StatusWarning {
+		t.Errorf("critical alert = %q <- %s", alert.Title, alert.PreviousStatus)
+	}
+	if alert.Priority != check.PriorityHigh {
+		t.Errorf("critical priority = %s, want high", alert.Priority)
+	}
+
+	alert = expectOne(t, h.run(ok))
+	if !alert.Recovery || alert.Status != check.StatusOK || alert.PreviousStatus != check.StatusCritical {
+		t.Errorf("recovery alert = %+v", alert)
+	}
+	// Routed like the alert it resolves, not at the OK result's priority
+	if alert.Priority != check.PriorityHigh {
+		t.Errorf("recovery priority = %s, want high", alert.Priority)
+	}
+	if _, open := h.state.Incident("test"); open {
+		t.Error("incident still open after recovery")
+	}
+
+	expectNone(t, h.run(ok))
+}
+
+func TestExecuteCheckDisableRecovery(t *testing.T) {
+	h := newHarness(t, check.Check{DisableRecovery: true})
+
+	expectOne(t, h.run(critical))
+	expectNone(t, h.run(ok))
+	if _, open := h.state.Incident("test"); open {
+		t.Error("incident still open after the check recovered")
+	}
+}
+
+func TestExecuteCheckErrorBacksOff(t *testing.T) {
+	h := newHarness(t, check.Check{})
+
+	expectOne(t, h.run(critical))
+	expectNone(t, h.runError(errors.New("connection refused")))
+	if h.rt.backoffMultiplier != 2 {
+		t.Errorf("backoff multiplier = %d, want 2", h.rt.backoffMultiplier)
+	}
+	if inc, _ := h.state.Incident("test"); inc.Status != "CRITICAL" {
+		t.Errorf("incident status = %q after an error, want CRITICAL", inc.Status)
+	}
+
+	// Still CRITICAL, so not re-sent
+	expectNone(t, h.run(critical))
+	if h.rt.backoffMultiplier != 1 {
+		t.Errorf("backoff multiplier after success = %d, want 1", h.rt.backoffMultiplier)
+	}
+}
+
+func TestExecuteCheckAlertOnError(t *testing.T) {
+	h := newHarness(t, check.Check{AlertOnError: true, FailureThreshold: 2})
+
+	expectNone(t, h.runError(errors.New("connection refused")))
+	alert := expectOne(t, h.runError(errors.New("connection reset")))
+	if alert.Status != check.StatusUnknown || alert.Message != "connection reset" {
+		t.Errorf("error alert = %s %q", alert.Status, alert.Message)
+	}
+	if h.rt.backoffMultiplier != 4 {
+		t.Errorf("backoff multiplier = %d, want 4", h.rt.backoffMultiplier)
+	}
+
+	// A different error message is not news
+	expectNone(t, h.runError(errors.New("connection refused")))
+
+	expectOne(t, h.run(ok))
+	if h.rt.backoffMultiplier != 1 {
+		t.Errorf("backoff multiplier after success = %d, want 1", h.rt.backoffMultiplier)
+	}
+}
+
+func TestExecuteCheckNewMessageIsSent(t *testing.T) {
+	h := newHarness(t, check.Check{})
+	price := func(p string) check.CheckResult {
+		return check.CheckResult{ShouldAlert: true, Title: "BTC price", Message: p}
+	}
+
+	expectOne(t, h.run(price("$60,000")))
+	expectNone(t, h.run(price("$60,000")))
+
+	alert := expectOne(t, h.run(price("$61,000")))
+	if alert.Repeat != 0 || alert.Title != "[WARNING] BTC price" || alert.Message != "$61,000" {
+		t.Errorf("update = %d %q %q", alert.Repeat, alert.Title, alert.Message)
+	}
+	expectNone(t, h.run(price("$61,000")))
+}
+
+func TestExecuteCheckRateLimitedIsRetried(t *testing.T) {
+	h := newHarness(t, check.Check{})
+
+	h.next, h.err = check.CheckResult{}, claude.ErrRateLimited
+	alerted, retry := h.s.executeCheck(context.Background(), h.c, h.rt)
+	if alerted || !retry {
+		t.Errorf("executeCheck = (%t, %t), want (false, true)", alerted, retry)
+	}
+	expectNone(t, h.n.take())
+	if h.rt.backoffMultiplier != 1 {
+		t.Errorf("backoff multiplier = %d, want 1", h.rt.backoffMultiplier)
+	}
+}
+
+func TestExecuteCheckWaitingForClaudeIsRetried(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, `{"content":[{"type":"text","text":"ok"}]}`)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := claude.NewClient(
+		claude.WithAPIKey("key"),
+		claude.WithAPIURL(srv.URL),
+		claude.WithLimits(claude.Limits{MaxConcurrent: 1}),
+		claude.WithLogger(log.New(io.Discard, "", 0)),
+	)
+	go client.AnalyzeText(context.Background(), "hold the only slot", "")
+	<-started
+
+	h := newHarness(t, check.Check{Timeout: 200 * time.Millisecond})
+	h.s.claude = client
+	h.c.Run = func(ctx context.Context, c claude.Analyzer) (check.CheckResult, error) {
+		_, err := c.AnalyzeText(ctx, "prompt", "")
+		return check.CheckResult{}, err
+	}
+
+	alerted, retry := h.s.executeCheck(context.Background(), h.c, h.rt)
+	if alerted || !retry {
+		t.Errorf("executeCheck = (%t, %t), want (false, true)", alerted, retry)
+	}
+	expectNone(t, h.n.take())
+	if h.rt.backoffMultiplier != 1 {
+		t.Errorf("backoff multiplier = %d, want 1", h.rt.backoffMultiplier)
+	}
+}
+
+func TestExecuteCheckThresholds(t *testing.T) {
+	h := newHarness(t, check.Check{FailureThreshold: 3, SuccessThreshold: 2})
+
+	expectNone(t, h.run(critical))
+	expectNone(t, h.run(critical))
+	expectNone(t, h.run(ok)) // resets the count
+	expectNone(t, h.run(critical))
+	expectNone(t, h.run(critical))
+	expectOne(t, h.run(critical))
+
+	expectNone(t, h.run(ok))
+	expectNone(t, h.run(critical)) // already alerting, status unchanged
+	expectNone(t, h.run(ok))
+	if alert := expectOne(t, h.run(ok)); !alert.Recovery {
+		t.Errorf("alert = %+v, want recovery", alert)
+	}
+}
+
+func TestExecuteCheckReminders(t *testing.T) {
+	policy := check.RenotifyPolicy{Interval: time.Nanosecond, MaxCount: 2, Escalate: true}
+	h := newHarness(t, check.Check{}, WithRenotify(policy))
+
+	first := expectOne(t, h.run(critical))
+	if first.Repeat != 0 {
+		t.Errorf("first alert repeat = %d", first.Repeat)
+	}
+
+	reminder := expectOne(t, h.run(critical))
+	if reminder.Repeat != 1 || reminder.Title != "Reminder: [CRITICAL] Down" {
+		t.Errorf("reminder = %d %q", reminder.Repeat, reminder.Title)
+	}
+	if reminder.Priority != check.PriorityUrgent {
+		t.Errorf("escalated priority = %s, want urgent", reminder.Priority)
+	}
+	// Escalation doesn't move the reminder to other routes
+	if reminder.RoutePriority() != check.PriorityHigh {
+		t.Errorf("route priority = %s, want high", reminder.RoutePriority())
+	}
+
+	expectOne(t, h.run(critical))
+	expectNone(t, h.run(critical)) // MaxCount reached
+
+	if alert := expectOne(t, h.run(ok)); alert.Priority != check.PriorityHigh {
+		t.Errorf("recovery priority = %s, want high", alert.Priority)
+	}
+}
+
+func TestExecuteCheckAcknowledgedStopsReminders(t *testing.T) {
+	h := newHarness(t, check.Check{}, WithRenotify(check.RenotifyPolicy{Interval: time.Nanosecond}))
+	acked := &ackState{Memory: h.state}
+	h.s.state = acked
+
+	expectOne(t, h.run(critical))
+	acked.acked = true
+	expectNone(t, h.run(critical))
+	expectOne(t, h.run(ok))
+}
+
+// ackState reports every open incident as acknowledged once acked is set
+type ackState struct {
+	*state.Memory
+	acked bool
+}
+
+func (s *ackState) Incident(checkName string) (state.Incident, bool) {
+	inc, ok := s.Memory.Incident(checkName)
+	if ok && s.acked {
+		inc.AckedAt = time.Now()
+	}
+	return inc, ok
+}
+
+func TestExecuteCheckFlapping(t *testing.T) {
+	h := newHarness(t, check.Check{Flap: check.FlapPolicy{Window: time.Hour, MaxChanges: 4}})
+
+	expectOne(t, h.run(critical))
+	expectOne(t, h.run(ok))
+	expectOne(t, h.run(critical))
+
+	alert := expectOne(t, h.run(ok))
+	if !alert.Flapping || alert.Title != "[FLAPPING] test is flapping" {
+		t.Fatalf("alert = %q flapping=%t, want flapping notification", alert.Title, alert.Flapping)
+	}
+	if alert.Priority != check.PriorityHigh {
+		t.Errorf("flapping priority = %s, want the incident's high", alert.Priority)
+	}
+
+	// Paused while flapping
+	expectNone(t, h.run(critical))
+	expectNone(t, h.run(ok))
+	expectNone(t, h.run(critical))
+
+	// Age the changes out of the window so the next run settles
+	for i := range h.rt.changes {
+		h.rt.changes[i] = h.rt.changes[i].Add(-2 * time.Hour)
+	}
+	alert = expectOne(t, h.run(critical))
+	if alert.Flapping || alert.Title != "[CRITICAL] test stopped flapping" || alert.Recovery {
+		t.Errorf("settled alert = %q flapping=%t recovery=%t", alert.Title, alert.Flapping, alert.Recovery)
+	}
+	if alert.Priority != check.PriorityHigh {
+		t.Errorf("settled priority = %s, want the incident's high", alert.Priority)
+	}
+
+	// Settling made CRITICAL the alerted state
+	expectNone(t, h.run(critical))
+	if alert := expectOne(t, h.run(ok)); !alert.Recovery {
+		t.Errorf("alert = %+v, want recovery", alert)
+	}
+}
+
+func TestExecuteCheckSettlesAtOK(t *testing.T) {
+	h := newHarness(t, check.Check{Flap: check.FlapPolicy{Window: time.Hour, MaxChanges: 2}})
+
+	expectOne(t, h.run(critical))
+	if alert := expectOne(t, h.run(ok)); !alert.Flapping {
+		t.Fatalf("alert = %q, want flapping notification", alert.Title)
+	}
+
+	for i := range h.rt.changes {
+		h.rt.changes[i] = h.rt.changes[i].Add(-2 * time.Hour)
+	}
+	alert := expectOne(t, h.run(ok))
+	if !alert.Recovery || alert.Title != "[OK] test stopped flapping" {
+		t.Errorf("settled alert = %q recovery=%t", alert.Title, alert.Recovery)
+	}
+	if _, open := h.state.Incident("test"); open {
+		t.Error("incident still open after settling at OK")
+	}
+}
+
+func TestResolveDropsQueuedDeliveries(t *testing.T) {
+	h := newHarness(t, check.Check{DisableRecovery: true})
+
+	expectOne(t, h.run(critical))
+	if _, err := h.state.Enqueue(state.Delivery{Notifier: "slack", CheckName: "test", Payload: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectNone(t, h.run(ok))
+	due, err := h.state.DueDeliveries(time.Now(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("%d deliveries still queued after the alert cleared", len(due))
+	}
+}
+
+func TestNextDelay(t *testing.T) {
+	c := check.Check{Interval: 10 * time.Minute}
+	now := time.Now()
+
+	if d, _ := nextDelay(c, nil, 1, now); d != 10*time.Minute {
+		t.Errorf("interval delay = %s", d)
+	}
+	if d, _ := nextDelay(c, nil, 4, now); d != 40*time.Minute {
+		t.Errorf("backed off delay = %s", d)
+	}
+	if d, _ := nextDelay(c, nil, 32, now); d != maxBackoffDuration {
+		t.Errorf("capped delay = %s, want %s", d, maxBackoffDuration)
+	}
+}
+
+func TestUnregisterDropsMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	s := New(nil, &recorder{}, state.NewMemory(), log.New(io.Discard, "", 0), WithMetrics(reg))
+	for _, name := range []string{"api", "web"} {
+		err := s.Register(check.Check{
+			Name:     name,
+			Interval: time.Minute,
+			Run: func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
+				return check.CheckResult{}, errors.New("refused")
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.RunOnce(context.Background())
+
+	if err := s.Unregister("web"); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	reg.WriteTo(&out)
+	if strings.Contains(out.String(), `check="web"`) {
+		t.Errorf("metrics still report the removed check:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `checkandping_check_backoff_multiplier{check="api"} 2`) {
+		t.Errorf("metrics lost the remaining check:\n%s", out.String())
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	s := New(nil, &recorder{}, state.NewMemory(), log.New(io.Discard, "", 0))
+	api := check.Check{Name: "api", Interval: time.Minute}
+
+	if err := s.Register(api); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register(api); !errors.Is(err, ErrDuplicateCheck) {
+		t.Errorf("registering api twice = %v, want ErrDuplicateCheck", err)
+	}
+	if len(s.checks) != 1 {
+		t.Errorf("%d checks registered, want 1", len(s.checks))
+	}
+
+	// The name is free again once unregistered
+	if err := s.Unregister("api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register(api); err != nil {
+		t.Errorf("re-registering api = %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// ErrUnknownCheck is returned for a check name that isn't registered
+var ErrUnknownCheck = errors.New("unknown check")
+
+// CheckStatus is a snapshot of a registered check's runtime state
+type CheckStatus struct {
+	Name     string
+	Interval time.Duration
+	Schedule string // cron expression, if the check has one
+
+	Paused  bool
+	Running bool
+	NextRun time.Time // zero until the first run is scheduled
+
+	LastRun      time.Time // zero if the check hasn't run yet
+	LastDuration time.Duration
+	LastStatus   check.Status
+	LastError    string // most recent error, kept after later successful runs
+	LastErrorAt  time.Time
+
+	BackoffMultiplier   int
+	ConsecutiveFailures int
+	Flapping            bool
+
+	Incident *state.Incident // open alert, nil if none
+}
+
+// Statuses returns a snapshot of every registered check, in registration order
+func (s *Scheduler) Statuses() []CheckStatus {
+	s.mu.Lock()
+	statuses := make([]CheckStatus, 0, len(s.checks))
+	for _, c := range s.checks {
+		statuses = append(statuses, s.snapshot(c))
+	}
+	s.mu.Unlock()
+
+	for i := range statuses {
+		statuses[i].Incident = s.incident(statuses[i].Name)
+	}
+	return statuses
+}
+
+// Status returns a snapshot of one registered check
+func (s *Scheduler) Status(name string) (CheckStatus, bool) {
+	s.mu.Lock()
+	c, ok := s.lookup(name)
+	var status CheckStatus
+	if ok {
+		status = s.snapshot(c)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return CheckStatus{}, false
+	}
+	status.Incident = s.incident(name)
+	return status, true
+}
+
+// Trigger asks a running scheduler to run a check now, even if it is
+// paused. A trigger while the check is already running or triggered runs
+// it once more afterwards.
+func (s *Scheduler) Trigger(name string) error {
+	rt, err := s.registered(name)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case rt.trigger <- struct{}{}:
+	default:
+		// Already triggered
+	}
+	return nil
+}
+
+// Pause stops scheduled runs of a check until Resume. Triggered runs still happen.
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume restarts scheduled runs of a paused check
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	rt, err := s.registered(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	rt.paused = paused
+	s.mu.Unlock()
+
+	if paused {
+		s.logger.Printf("[%s] paused", name)
+	} else {
+		s.logger.Printf("[%s] resumed", name)
+	}
+	return nil
+}
+
+// paused reports whether scheduled runs of a check are paused
+func (s *Scheduler) paused(rt *checkRuntime) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rt.paused
+}
+
+// runtime returns a registered check's runtime state
+func (s *Scheduler) runtime(name string) *checkRuntime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runtimes[name]
+}
+
+// registered returns a check's runtime state, or ErrUnknownCheck
+func (s *Scheduler) registered(name string) (*checkRuntime, error) {
+	rt := s.runtime(name)
+	if rt == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCheck, name)
+	}
+	return rt, nil
+}
+
+// lookup finds a registered check by name. The caller must hold s.mu.
+func (s *Scheduler) lookup(name string) (check.Check, bool) {
+	for _, c := range s.checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return check.Check{}, false
+}
+
+// snapshot copies a check's runtime state. The caller must hold s.mu.
+func (s *Scheduler) snapshot(c check.Check) CheckStatus {
+	rt := s.runtimes[c.Name]
+	return CheckStatus{
+		Name:                c.Name,
+		Interval:            c.Interval,
+		Schedule:            c.Schedule,
+		Paused:              rt.paused,
+		Running:             rt.running,
+		NextRun:             rt.nextRun,
+		LastRun:             rt.lastRun,
+		LastDuration:        rt.lastDuration,
+		LastStatus:          rt.lastStatus,
+		LastError:           rt.lastError,
+		LastErrorAt:         rt.lastErrorAt,
+		BackoffMultiplier:   rt.backoffMultiplier,
+		ConsecutiveFailures: rt.consecutiveFailures,
+		Flapping:            rt.flapping,
+	}
+}
+
+// incident returns the check's open alert from the state backend, if any
+func (s *Scheduler) incident(name string) *state.Incident {
+	incident, ok := s.state.Incident(name)
+	if !ok {
+		return nil
+	}
+	return &incident
+}
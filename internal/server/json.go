@@ -0,0 +1,87 @@
+package server
+
+import (
+	"time"
+
+	"github.com/murr/check-and-ping/internal/scheduler"
+)
+
+// checkJSON is the API representation of a check's status
+type checkJSON struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+
+	Paused  bool       `json:"paused"`
+	Running bool       `json:"running"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMS *int64     `json:"last_duration_ms,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+
+	BackoffMultiplier   int  `json:"backoff_multiplier"`
+	ConsecutiveFailures int  `json:"consecutive_failures"`
+	Flapping            bool `json:"flapping"`
+
+	Alert *alertJSON `json:"alert"` // open alert, null if none
+}
+
+// alertJSON is the API representation of a check's open alert
+type alertJSON struct {
+	Status       string     `json:"status"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	AlertedAt    time.Time  `json:"alerted_at"`
+	Count        int        `json:"count"`
+	Acknowledged bool       `json:"acknowledged"`
+	AckedAt      *time.Time `json:"acked_at,omitempty"`
+	AckComment   string     `json:"ack_comment,omitempty"`
+}
+
+func newCheckJSON(s scheduler.CheckStatus) checkJSON {
+	c := checkJSON{
+		Name:                s.Name,
+		Schedule:            s.Schedule,
+		Paused:              s.Paused,
+		Running:             s.Running,
+		NextRun:             timePtr(s.NextRun),
+		LastRun:             timePtr(s.LastRun),
+		LastError:           s.LastError,
+		LastErrorAt:         timePtr(s.LastErrorAt),
+		BackoffMultiplier:   s.BackoffMultiplier,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		Flapping:            s.Flapping,
+	}
+	if s.Schedule == "" {
+		c.Interval = s.Interval.String()
+	}
+	if !s.LastRun.IsZero() {
+		ms := s.LastDuration.Milliseconds()
+		c.LastDurationMS = &ms
+		c.LastStatus = s.LastStatus.String()
+	}
+
+	if s.Incident != nil {
+		c.Alert = &alertJSON{
+			Status:       s.Incident.Status,
+			OpenedAt:     s.Incident.OpenedAt,
+			AlertedAt:    s.Incident.AlertedAt,
+			Count:        s.Incident.Count,
+			Acknowledged: s.Incident.Acknowledged(),
+			AckedAt:      timePtr(s.Incident.AckedAt),
+			AckComment:   s.Incident.AckComment,
+		}
+	}
+
+	return c
+}
+
+// timePtr returns nil for the zero time so it is omitted from JSON
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
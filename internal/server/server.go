@@ -0,0 +1,214 @@
+// Package server exposes the running scheduler over HTTP
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/scheduler"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server serves the status API for a scheduler:
+//
+//	GET  /api/checks               every check's status
+//	GET  /api/checks/{name}        one check's status
+//	POST /api/checks/{name}/trigger run the check now
+//	POST /api/checks/{name}/pause   stop scheduled runs
+//	POST /api/checks/{name}/resume  restart scheduled runs
+//	*    /ping/{name}               heartbeat check-in (also /start and /fail)
+//	GET  /metrics                   Prometheus metrics, if WithMetrics is set
+type Server struct {
+	scheduler *scheduler.Scheduler
+	token     string
+	logger    *log.Logger
+	metrics   http.Handler
+	mux       *http.ServeMux
+	http      *http.Server
+}
+
+// Option configures the Server
+type Option func(*Server)
+
+// WithToken requires "Authorization: Bearer <token>" on every request
+// except heartbeat pings
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithLogger sets the logger for server errors
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithMetrics serves h, usually a metrics.Registry's Handler, at /metrics
+func WithMetrics(h http.Handler) Option {
+	return func(s *Server) {
+		s.metrics = h
+	}
+}
+
+// New creates a status server for the scheduler
+func New(sched *scheduler.Scheduler, opts ...Option) *Server {
+	s := &Server{
+		scheduler: sched,
+		logger:    log.Default(),
+		mux:       http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /api/checks", s.handleList)
+	s.mux.HandleFunc("GET /api/checks/{name}", s.handleGet)
+	s.mux.HandleFunc("POST /api/checks/{name}/trigger", s.handleControl(sched.Trigger, "triggered"))
+	s.mux.HandleFunc("POST /api/checks/{name}/pause", s.handleControl(sched.Pause, "paused"))
+	s.mux.HandleFunc("POST /api/checks/{name}/resume", s.handleControl(sched.Resume, "resumed"))
+	s.mux.HandleFunc("/ping/{name}", s.handlePing)
+	s.mux.HandleFunc("/ping/{name}/{kind}", s.handlePing)
+	if s.metrics != nil {
+		s.mux.Handle("GET /metrics", s.metrics)
+	}
+
+	return s
+}
+
+// Handler returns the server's routes, wrapped in token auth if configured.
+// Heartbeat pings are exempt so jobs can ping with a bare curl: a ping can
+// only check in, not read status or control checks.
+func (s *Server) Handler() http.Handler {
+	if s.token == "" {
+		return s.mux
+	}
+
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/ping/") {
+			s.mux.ServeHTTP(w, r)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Start listens on addr and serves in the background until Stop
+func (s *Server) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	s.http = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Printf("status server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop gracefully shuts down the server
+func (s *Server) Stop() {
+	if s.http == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.logger.Printf("status server shutdown: %v", err)
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	statuses := s.scheduler.Statuses()
+	checks := make([]checkJSON, 0, len(statuses))
+	for _, status := range statuses {
+		checks = append(checks, newCheckJSON(status))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"checks": checks})
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	status, ok := s.scheduler.Status(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown check")
+		return
+	}
+	writeJSON(w, http.StatusOK, newCheckJSON(status))
+}
+
+// handleControl runs a scheduler action on the named check
+func (s *Server) handleControl(action func(name string) error, done string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := action(name); err != nil {
+			if errors.Is(err, scheduler.ErrUnknownCheck) {
+				writeError(w, http.StatusNotFound, "unknown check")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"check": name, "result": done})
+	}
+}
+
+// handlePing records a heartbeat ping. Any method works and no token is
+// needed, so jobs can ping with a bare curl or wget.
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	kind, err := state.ParsePingKind(r.PathValue("kind"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := s.scheduler.Ping(name, kind); err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownCheck):
+			writeError(w, http.StatusNotFound, "unknown check")
+		case errors.Is(err, scheduler.ErrNotHeartbeat):
+			writeError(w, http.StatusBadRequest, "not a heartbeat check")
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"check": name, "result": string(kind)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/murr/check-and-ping/internal/check"
+	"github.com/murr/check-and-ping/internal/claude"
+	"github.com/murr/check-and-ping/internal/probe"
+	"github.com/murr/check-and-ping/internal/scheduler"
+	"github.com/murr/check-and-ping/internal/state"
+)
+
+// discard is a notifier that drops every alert
+type discard struct{}
+
+func (discard) Name() string                                      { return "discard" }
+func (discard) Send(ctx context.Context, alert check.Alert) error { return nil }
+
+// newTestServer serves a scheduler with a "backup" heartbeat check and a
+// "web" check that doesn't take pings
+func newTestServer(t *testing.T, opts ...Option) (http.Handler, *state.Memory) {
+	t.Helper()
+	st := state.NewMemory()
+	sched := scheduler.New(nil, discard{}, st, log.New(io.Discard, "", 0))
+
+	hb := &probe.Heartbeat{Pings: st, Period: time.Hour}
+	for _, c := range []check.Check{
+		hb.Check("backup", time.Minute),
+		{
+			Name:     "web",
+			Interval: time.Minute,
+			Run: func(ctx context.Context, _ claude.Analyzer) (check.CheckResult, error) {
+				return check.CheckResult{}, nil
+			},
+		},
+	} {
+		if err := sched.Register(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts = append([]Option{WithLogger(log.New(io.Discard, "", 0))}, opts...)
+	return New(sched, opts...).Handler(), st
+}
+
+func serve(h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPing(t *testing.T) {
+	h, st := newTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		kind   state.PingKind // the ping time expected to be set
+	}{
+		{"POST", "/ping/backup", http.StatusOK, state.PingSuccess},
+		{"GET", "/ping/backup/start", http.StatusOK, state.PingStart},
+		{"HEAD", "/ping/backup/fail", http.StatusOK, state.PingFail},
+		{"GET", "/ping/backup/success", http.StatusOK, state.PingSuccess},
+		{"GET", "/ping/backup/finish", http.StatusNotFound, ""},
+		{"GET", "/ping/missing", http.StatusNotFound, ""},
+		{"GET", "/ping/missing/fail", http.StatusNotFound, ""},
+		{"POST", "/ping/web", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			before, _ := st.Heartbeat("backup")
+			rec := serve(h, tt.method, tt.path, "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			after, _ := st.Heartbeat("backup")
+			changed := map[state.PingKind]bool{
+				state.PingSuccess: after.LastSuccess.After(before.LastSuccess),
+				state.PingStart:   after.LastStart.After(before.LastStart),
+				state.PingFail:    after.LastFail.After(before.LastFail),
+			}
+			for kind, ok := range changed {
+				if ok != (kind == tt.kind) {
+					t.Errorf("%s ping recorded = %t", kind, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestPingWithoutToken(t *testing.T) {
+	h, st := newTestServer(t, WithToken("secret"))
+
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"GET", "/ping/backup", "", http.StatusOK},
+		{"POST", "/ping/backup/start", "wrong", http.StatusOK},
+		{"GET", "/ping/missing", "", http.StatusNotFound},
+		{"GET", "/api/checks", "", http.StatusUnauthorized},
+		{"GET", "/api/checks", "wrong", http.StatusUnauthorized},
+		{"GET", "/api/checks", "secret", http.StatusOK},
+		{"POST", "/api/checks/backup/trigger", "", http.StatusUnauthorized},
+		{"GET", "/pings/backup", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		if rec := serve(h, tt.method, tt.path, tt.token); rec.Code != tt.status {
+			t.Errorf("%s %s with token %q = %d, want %d", tt.method, tt.path, tt.token, rec.Code, tt.status)
+		}
+	}
+
+	if hb, ok := st.Heartbeat("backup"); !ok || hb.LastSuccess.IsZero() || hb.LastStart.IsZero() {
+		t.Errorf("heartbeat = %+v, want success and start pings", hb)
+	}
+}
+
+func TestControlUnknownCheck(t *testing.T) {
+	h, _ := newTestServer(t)
+
+	for _, tt := range []struct{ method, path string }{
+		{"GET", "/api/checks/missing"},
+		{"POST", "/api/checks/missing/trigger"},
+		{"POST", "/api/checks/missing/pause"},
+	} {
+		if rec := serve(h, tt.method, tt.path, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s = %d, want 404", tt.method, tt.path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pings is implemented by state backends that store heartbeat pings
+type Pings interface {
+	// RecordPing stores a ping received for a heartbeat check
+	RecordPing(checkName string, kind PingKind, at time.Time) error
+	// Heartbeat returns the latest pings for a check, if it has received any
+	Heartbeat(checkName string) (Heartbeat, bool)
+}
+
+// PingKind is what a heartbeat ping reports about the job
+type PingKind string
+
+const (
+	PingSuccess PingKind = "success" // the job finished, or is simply alive
+	PingStart   PingKind = "start"   // the job started
+	PingFail    PingKind = "fail"    // the job finished and failed
+)
+
+// ParsePingKind converts a ping kind name into a PingKind. An empty string
+// is a success ping.
+func ParsePingKind(s string) (PingKind, error) {
+	switch PingKind(s) {
+	case PingSuccess, "":
+		return PingSuccess, nil
+	case PingStart:
+		return PingStart, nil
+	case PingFail:
+		return PingFail, nil
+	default:
+		return "", fmt.Errorf("unknown ping kind: %s", s)
+	}
+}
+
+// Heartbeat is the latest ping of each kind a check has received. Unset
+// times are zero.
+type Heartbeat struct {
+	LastSuccess time.Time
+	LastStart   time.Time
+	LastFail    time.Time
+}
+
+// LastPing returns the time of the most recent ping of any kind
+func (h Heartbeat) LastPing() time.Time {
+	last := h.LastSuccess
+	if h.LastStart.After(last) {
+		last = h.LastStart
+	}
+	if h.LastFail.After(last) {
+		last = h.LastFail
+	}
+	return last
+}
+
+// Failed reports whether the job's most recent run reported failure
+func (h Heartbeat) Failed() bool {
+	return h.LastFail.After(h.LastSuccess)
+}
+
+// Running reports whether the job has started and not yet finished
+func (h Heartbeat) Running() bool {
+	return h.LastStart.After(h.LastSuccess) && h.LastStart.After(h.LastFail)
+}
+
+// record returns the heartbeat updated with a ping
+func (h Heartbeat) record(kind PingKind, at time.Time) Heartbeat {
+	switch kind {
+	case PingStart:
+		h.LastStart = at
+	case PingFail:
+		h.LastFail = at
+	default:
+		h.LastSuccess = at
+	}
+	return h
+}
+
+// RecordPing stores a ping for a heartbeat check
+func (m *Memory) RecordPing(checkName string, kind PingKind, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pings[checkName] = m.pings[checkName].record(kind, at)
+	return nil
+}
+
+// Heartbeat returns the latest pings for a check
+func (m *Memory) Heartbeat(checkName string) (Heartbeat, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok := m.pings[checkName]
+	return h, ok
+}
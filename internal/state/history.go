@@ -0,0 +1,59 @@
+package state
+
+import "time"
+
+// History is implemented by state backends that keep a log of check runs
+// and notification attempts
+type History interface {
+	// RecordRun logs a single check execution
+	RecordRun(run RunRecord) error
+	// RecordNotification logs a single delivery attempt by one notifier
+	RecordNotification(n NotificationRecord) error
+}
+
+// RunRecord is one check execution
+type RunRecord struct {
+	ID          int64
+	CheckName   string
+	StartedAt   time.Time
+	Duration    time.Duration
+	Error       string // Empty if the check ran successfully
+	ShouldAlert bool
+	ResultHash  string // Hash of the alerting result, empty if not alerting
+}
+
+// Failed reports whether the run errored or called for an alert
+func (r RunRecord) Failed() bool {
+	return r.Error != "" || r.ShouldAlert
+}
+
+// NotificationRecord is one delivery attempt of an alert by a single notifier
+type NotificationRecord struct {
+	ID        int64
+	CheckName string
+	Notifier  string
+	Title     string
+	Recovery  bool
+	SentAt    time.Time
+	Success   bool
+	Error     string // Empty on success
+}
+
+// RunQuery filters check run history. Zero values match everything.
+type RunQuery struct {
+	CheckName  string
+	Since      time.Time
+	Until      time.Time
+	FailedOnly bool // Only runs that errored or alerted
+	Limit      int  // Maximum rows, newest first (0 = no limit)
+}
+
+// NotificationQuery filters notification history. Zero values match everything.
+type NotificationQuery struct {
+	CheckName string
+	Notifier  string
+	Since     time.Time
+	Until     time.Time
+	Success   *bool // Only successful (true) or failed (false) attempts
+	Limit     int   // Maximum rows, newest first (0 = no limit)
+}
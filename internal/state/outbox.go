@@ -0,0 +1,151 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Outbox is implemented by state backends that keep notifications waiting
+// to be retried, and those that ran out of attempts
+type Outbox interface {
+	// Enqueue stores a delivery to retry and returns its ID
+	Enqueue(d Delivery) (int64, error)
+	// DueDeliveries returns queued deliveries whose next attempt is at or
+	// before now, oldest first
+	DueDeliveries(now time.Time, limit int) ([]Delivery, error)
+	// Reschedule records another failed attempt and when to try next
+	Reschedule(id int64, attempts int, next time.Time, lastError string) error
+	// Delivered removes a delivery that succeeded
+	Delivered(id int64) error
+	// DeadLetter moves a delivery that won't be retried again to the dead letters
+	DeadLetter(id int64, attempts int, lastError string) error
+	// CancelDeliveries removes a check's queued deliveries for notifier, or
+	// for every notifier if it is empty, and returns how many were removed
+	CancelDeliveries(checkName string, notifier string) (int, error)
+	// DeadLetters returns deliveries that were given up on, newest first
+	DeadLetters(limit int) ([]Delivery, error)
+}
+
+// Delivery is a notification for one notifier that failed and is queued
+// for another attempt
+type Delivery struct {
+	ID          int64
+	Notifier    string
+	CheckName   string
+	Payload     []byte // the alert, JSON encoded
+	Attempts    int    // attempts made so far
+	NextAttempt time.Time
+	LastError   string
+	CreatedAt   time.Time
+}
+
+// Enqueue stores a delivery to retry
+func (m *Memory) Enqueue(d Delivery) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextDelivery++
+	d.ID = m.nextDelivery
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	m.outbox = append(m.outbox, d)
+	return d.ID, nil
+}
+
+// DueDeliveries returns queued deliveries due at now, oldest first
+func (m *Memory) DueDeliveries(now time.Time, limit int) ([]Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var due []Delivery
+	for _, d := range m.outbox {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+		if limit > 0 && len(due) == limit {
+			break
+		}
+	}
+	return due, nil
+}
+
+// Reschedule records another failed attempt
+func (m *Memory) Reschedule(id int64, attempts int, next time.Time, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.delivery(id)
+	if err != nil {
+		return err
+	}
+	m.outbox[i].Attempts = attempts
+	m.outbox[i].NextAttempt = next
+	m.outbox[i].LastError = lastError
+	return nil
+}
+
+// Delivered removes a delivery that succeeded
+func (m *Memory) Delivered(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.delivery(id)
+	if err != nil {
+		return err
+	}
+	m.outbox = slices.Delete(m.outbox, i, i+1)
+	return nil
+}
+
+// DeadLetter moves a delivery to the dead letters
+func (m *Memory) DeadLetter(id int64, attempts int, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.delivery(id)
+	if err != nil {
+		return err
+	}
+	d := m.outbox[i]
+	d.Attempts = attempts
+	d.LastError = lastError
+	m.outbox = slices.Delete(m.outbox, i, i+1)
+	m.deadLetters = append(m.deadLetters, d)
+	return nil
+}
+
+// CancelDeliveries removes a check's queued deliveries
+func (m *Memory) CancelDeliveries(checkName string, notifier string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before := len(m.outbox)
+	m.outbox = slices.DeleteFunc(m.outbox, func(d Delivery) bool {
+		return d.CheckName == checkName && (notifier == "" || d.Notifier == notifier)
+	})
+	return before - len(m.outbox), nil
+}
+
+// DeadLetters returns deliveries that were given up on, newest first
+func (m *Memory) DeadLetters(limit int) ([]Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	letters := slices.Clone(m.deadLetters)
+	slices.Reverse(letters)
+	if limit > 0 && len(letters) > limit {
+		letters = letters[:limit]
+	}
+	return letters, nil
+}
+
+// delivery returns the index of a queued delivery. The caller must hold m.mu.
+func (m *Memory) delivery(id int64) (int, error) {
+	i := slices.IndexFunc(m.outbox, func(d Delivery) bool { return d.ID == id })
+	if i < 0 {
+		return 0, fmt.Errorf("no queued delivery with id %d", id)
+	}
+	return i, nil
+}
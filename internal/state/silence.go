@@ -0,0 +1,74 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+)
+
+// ErrNoIncident is returned when acknowledging a check with no open alert
+var ErrNoIncident = errors.New("no open alert")
+
+// Silences is implemented by state backends that store silences and
+// acknowledgements
+type Silences interface {
+	// AddSilence stores a silence and returns its ID
+	AddSilence(s Silence) (int64, error)
+	// ListSilences returns silences, newest first. Expired ones are
+	// included only if includeExpired is set.
+	ListSilences(includeExpired bool) ([]Silence, error)
+	// ExpireSilence ends a silence now
+	ExpireSilence(id int64) error
+	// Silenced returns the first active silence matching a check and its tags
+	Silenced(checkName string, tags []string, now time.Time) (Silence, bool)
+	// Acknowledge marks a check's open alert as known, suppressing
+	// reminders until its status changes or it resolves
+	Acknowledge(checkName, comment string) error
+}
+
+// Silence mutes notifications for matching checks until it expires.
+// A snooze is a silence for a single check.
+type Silence struct {
+	ID        int64
+	CheckGlob string   // glob on the check name, e.g. "court-*" (empty matches any)
+	Tags      []string // alert has any of these tags (empty matches any)
+	Comment   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Validate checks that the silence has a matcher, a valid glob and an expiry
+func (s Silence) Validate() error {
+	if s.CheckGlob == "" && len(s.Tags) == 0 {
+		return fmt.Errorf("silence needs a check or tag matcher")
+	}
+	if s.CheckGlob != "" {
+		if _, err := path.Match(s.CheckGlob, ""); err != nil {
+			return fmt.Errorf("invalid check glob %q: %w", s.CheckGlob, err)
+		}
+	}
+	if s.ExpiresAt.IsZero() {
+		return fmt.Errorf("silence needs an expiry")
+	}
+	return nil
+}
+
+// Active reports whether the silence is in effect at now
+func (s Silence) Active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// Matches reports whether the silence applies to a check with the given tags
+func (s Silence) Matches(checkName string, tags []string) bool {
+	if s.CheckGlob != "" {
+		if ok, _ := path.Match(s.CheckGlob, checkName); !ok {
+			return false
+		}
+	}
+	if len(s.Tags) > 0 && !slices.ContainsFunc(tags, func(t string) bool { return slices.Contains(s.Tags, t) }) {
+		return false
+	}
+	return true
+}
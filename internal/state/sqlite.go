@@ -3,21 +3,59 @@ package state
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const (
+	// DefaultRetention is how long run and notification history is kept
+	DefaultRetention = 30 * 24 * time.Hour
+	pruneInterval    = time.Hour
+	// busyTimeout is how long a write waits for a lock held by another
+	// process, such as the CLI writing silences while the daemon runs
+	busyTimeout = 5 * time.Second
+)
+
 // SQLite implements persistent state tracking using SQLite
 type SQLite struct {
 	db *sql.DB
 	mu sync.Mutex
+
+	retention time.Duration
+	noPrune   bool
+	stopPrune chan struct{}
+	pruneDone chan struct{}
+}
+
+// SQLiteOption configures the SQLite state tracker
+type SQLiteOption func(*SQLite)
+
+// WithRetention sets how long history is kept before being pruned.
+// Negative keeps history forever; zero keeps DefaultRetention.
+func WithRetention(d time.Duration) SQLiteOption {
+	return func(s *SQLite) {
+		if d == 0 {
+			d = DefaultRetention
+		}
+		s.retention = d
+	}
+}
+
+// WithoutPruning doesn't start the background loop that prunes history and
+// the cache, for short-lived processes such as CLI commands that share the
+// database with a running daemon
+func WithoutPruning() SQLiteOption {
+	return func(s *SQLite) {
+		s.noPrune = true
+	}
 }
 
 // NewSQLite creates a new SQLite state tracker
-func NewSQLite(dbPath string) (*SQLite, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+func NewSQLite(dbPath string, opts ...SQLiteOption) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", withBusyTimeout(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -27,7 +65,13 @@ func NewSQLite(dbPath string) (*SQLite, error) {
 		CREATE TABLE IF NOT EXISTS alert_state (
 			check_name TEXT PRIMARY KEY,
 			result_hash TEXT NOT NULL,
-			alerted_at DATETIME NOT NULL
+			alerted_at DATETIME NOT NULL,
+			opened_at DATETIME,
+			alert_count INTEGER NOT NULL DEFAULT 1,
+			status TEXT NOT NULL DEFAULT '',
+			priority TEXT NOT NULL DEFAULT '',
+			acked_at DATETIME,
+			ack_comment TEXT NOT NULL DEFAULT ''
 		)
 	`)
 	if err != nil {
@@ -35,43 +79,160 @@ func NewSQLite(dbPath string) (*SQLite, error) {
 		return nil, fmt.Errorf("create table: %w", err)
 	}
 
-	return &SQLite{db: db}, nil
-}
+	// Databases created by older versions lack the incident columns
+	if err := addColumnIfMissing(db, "alert_state", "opened_at", "DATETIME"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumnIfMissing(db, "alert_state", "alert_count", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumnIfMissing(db, "alert_state", "status", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumnIfMissing(db, "alert_state", "priority", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumnIfMissing(db, "alert_state", "acked_at", "DATETIME"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumnIfMissing(db, "alert_state", "ack_comment", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-// ShouldAlert returns true if this hash hasn't been alerted
-func (s *SQLite) ShouldAlert(checkName string, resultHash string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// Per-notifier deliveries of an alert still being sent
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notifier_state (
+			check_name TEXT NOT NULL,
+			notifier TEXT NOT NULL,
+			alert_key TEXT NOT NULL,
+			delivered_at DATETIME NOT NULL,
+			PRIMARY KEY (check_name, notifier)
+		)
+	`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create notifier_state table: %w", err)
+	}
 
-	var storedHash string
-	err := s.db.QueryRow(
-		"SELECT result_hash FROM alert_state WHERE check_name = ?",
-		checkName,
-	).Scan(&storedHash)
+	if err := createHistoryTables(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := createCacheTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := createSilenceTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := createHeartbeatTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := createOutboxTables(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLite{
+		db:        db,
+		retention: DefaultRetention,
+		stopPrune: make(chan struct{}),
+		pruneDone: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.noPrune {
+		close(s.pruneDone)
+	} else {
+		go s.pruneLoop(s.retention)
+	}
+
+	return s, nil
+}
 
-	if err == sql.ErrNoRows {
-		return true
+// withBusyTimeout adds busyTimeout to a database path, unless it sets one
+func withBusyTimeout(dbPath string) string {
+	if strings.Contains(dbPath, "_timeout=") {
+		return dbPath
+	}
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
 	}
+	return fmt.Sprintf("%s%s_busy_timeout=%d", dbPath, sep, busyTimeout.Milliseconds())
+}
+
+// addColumnIfMissing adds a column to an existing table
+func addColumnIfMissing(db *sql.DB, table, column, colType string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
-		// On error, err on the side of alerting
-		return true
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			ctype     string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("inspect %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, colType)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
 	}
 
-	return storedHash != resultHash
+	return nil
 }
 
 // MarkAlerted records that an alert was sent
-func (s *SQLite) MarkAlerted(checkName string, resultHash string) error {
+func (s *SQLite) MarkAlerted(checkName string, resultHash string, status string, priority string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := time.Now()
 	_, err := s.db.Exec(`
-		INSERT INTO alert_state (check_name, result_hash, alerted_at)
-		VALUES (?, ?, ?)
+		INSERT INTO alert_state (check_name, result_hash, alerted_at, opened_at, status, priority)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(check_name) DO UPDATE SET
+			alert_count = CASE WHEN alert_state.status = excluded.status
+				THEN alert_state.alert_count + 1 ELSE 1 END,
+			acked_at = CASE WHEN alert_state.status = excluded.status
+				THEN alert_state.acked_at ELSE NULL END,
+			ack_comment = CASE WHEN alert_state.status = excluded.status
+				THEN alert_state.ack_comment ELSE '' END,
 			result_hash = excluded.result_hash,
-			alerted_at = excluded.alerted_at
-	`, checkName, resultHash, time.Now())
+			alerted_at = excluded.alerted_at,
+			status = excluded.status,
+			priority = excluded.priority
+	`, checkName, resultHash, now, now, status, priority)
 
 	if err != nil {
 		return fmt.Errorf("upsert alert state: %w", err)
@@ -80,6 +241,35 @@ func (s *SQLite) MarkAlerted(checkName string, resultHash string) error {
 	return nil
 }
 
+// Incident returns the open alert for a check
+func (s *SQLite) Incident(checkName string) (Incident, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		inc      Incident
+		openedAt sql.NullTime
+		ackedAt  sql.NullTime
+	)
+	err := s.db.QueryRow(
+		"SELECT result_hash, alerted_at, opened_at, alert_count, status, priority, acked_at, ack_comment FROM alert_state WHERE check_name = ?",
+		checkName,
+	).Scan(&inc.Hash, &inc.AlertedAt, &openedAt, &inc.Count, &inc.Status, &inc.Priority, &ackedAt, &inc.AckComment)
+	if err != nil {
+		return Incident{}, false
+	}
+
+	inc.OpenedAt = inc.AlertedAt
+	if openedAt.Valid {
+		inc.OpenedAt = openedAt.Time
+	}
+	if ackedAt.Valid {
+		inc.AckedAt = ackedAt.Time
+	}
+
+	return inc, true
+}
+
 // Clear removes state for a check
 func (s *SQLite) Clear(checkName string) error {
 	s.mu.Lock()
@@ -89,11 +279,77 @@ func (s *SQLite) Clear(checkName string) error {
 	if err != nil {
 		return fmt.Errorf("delete alert state: %w", err)
 	}
+	if _, err := s.db.Exec("DELETE FROM notifier_state WHERE check_name = ?", checkName); err != nil {
+		return fmt.Errorf("delete notifier state: %w", err)
+	}
+
+	return nil
+}
+
+// DeliveredTo returns the notifiers that delivered the alert identified by key
+func (s *SQLite) DeliveredTo(checkName string, key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(
+		"SELECT notifier FROM notifier_state WHERE check_name = ? AND alert_key = ? ORDER BY notifier",
+		checkName, key,
+	)
+	if err != nil {
+		// On error, err on the side of sending to everyone
+		return nil
+	}
+	defer rows.Close()
+
+	var notifiers []string
+	for rows.Next() {
+		var notifier string
+		if err := rows.Scan(&notifier); err != nil {
+			return nil
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if rows.Err() != nil {
+		return nil
+	}
+
+	return notifiers
+}
+
+// MarkDelivered records that a notifier delivered the alert identified by key
+func (s *SQLite) MarkDelivered(checkName string, notifier string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO notifier_state (check_name, notifier, alert_key, delivered_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(check_name, notifier) DO UPDATE SET
+			alert_key = excluded.alert_key,
+			delivered_at = excluded.delivered_at
+	`, checkName, notifier, key, time.Now())
+	if err != nil {
+		return fmt.Errorf("upsert notifier state: %w", err)
+	}
+
+	return nil
+}
+
+// ResetDelivered forgets a check's per-notifier deliveries
+func (s *SQLite) ResetDelivered(checkName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM notifier_state WHERE check_name = ?", checkName); err != nil {
+		return fmt.Errorf("delete notifier state: %w", err)
+	}
 
 	return nil
 }
 
-// Close closes the database connection
+// Close stops history pruning and closes the database connection
 func (s *SQLite) Close() error {
+	close(s.stopPrune)
+	<-s.pruneDone
 	return s.db.Close()
 }
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// createCacheTable creates the Claude response cache table
+func createCacheTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS claude_cache (
+			cache_key TEXT PRIMARY KEY,
+			response TEXT NOT NULL,
+			expires_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create cache table: %w", err)
+	}
+	return nil
+}
+
+// CacheGet returns an unexpired cached response for key
+func (s *SQLite) CacheGet(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var response string
+	err := s.db.QueryRow(
+		"SELECT response FROM claude_cache WHERE cache_key = ? AND expires_at > ?",
+		key, time.Now().UTC(),
+	).Scan(&response)
+	if err != nil {
+		return "", false
+	}
+
+	return response, true
+}
+
+// CacheSet stores a response for key until ttl elapses
+func (s *SQLite) CacheSet(key, response string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO claude_cache (cache_key, response, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(cache_key) DO UPDATE SET
+			response = excluded.response,
+			expires_at = excluded.expires_at
+	`, key, response, time.Now().Add(ttl).UTC())
+	if err != nil {
+		return fmt.Errorf("upsert cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// pruneCache deletes expired cache entries
+func (s *SQLite) pruneCache() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM claude_cache WHERE expires_at <= ?", time.Now().UTC()); err != nil {
+		return fmt.Errorf("prune cache: %w", err)
+	}
+	return nil
+}
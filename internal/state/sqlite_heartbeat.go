@@ -0,0 +1,72 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// createHeartbeatTable creates the table of heartbeat pings
+func createHeartbeatTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS heartbeats (
+			check_name TEXT PRIMARY KEY,
+			last_success DATETIME,
+			last_start DATETIME,
+			last_fail DATETIME
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create heartbeats table: %w", err)
+	}
+	return nil
+}
+
+// pingColumns maps each ping kind to the column holding its latest time
+var pingColumns = map[PingKind]string{
+	PingSuccess: "last_success",
+	PingStart:   "last_start",
+	PingFail:    "last_fail",
+}
+
+// RecordPing stores a ping for a heartbeat check
+func (s *SQLite) RecordPing(checkName string, kind PingKind, at time.Time) error {
+	column, ok := pingColumns[kind]
+	if !ok {
+		return fmt.Errorf("unknown ping kind: %s", kind)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(fmt.Sprintf(`
+		INSERT INTO heartbeats (check_name, %[1]s) VALUES (?, ?)
+		ON CONFLICT(check_name) DO UPDATE SET %[1]s = excluded.%[1]s
+	`, column), checkName, at.UTC())
+	if err != nil {
+		return fmt.Errorf("record ping: %w", err)
+	}
+
+	return nil
+}
+
+// Heartbeat returns the latest pings for a check
+func (s *SQLite) Heartbeat(checkName string) (Heartbeat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var success, start, fail sql.NullTime
+	err := s.db.QueryRow(
+		"SELECT last_success, last_start, last_fail FROM heartbeats WHERE check_name = ?",
+		checkName,
+	).Scan(&success, &start, &fail)
+	if err != nil {
+		return Heartbeat{}, false
+	}
+
+	return Heartbeat{
+		LastSuccess: success.Time,
+		LastStart:   start.Time,
+		LastFail:    fail.Time,
+	}, true
+}
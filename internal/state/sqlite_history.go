@@ -0,0 +1,273 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createHistoryTables creates the run and notification history tables
+func createHistoryTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS check_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			check_name TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			should_alert BOOLEAN NOT NULL,
+			result_hash TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_check_runs_check ON check_runs (check_name, started_at);
+		CREATE INDEX IF NOT EXISTS idx_check_runs_started ON check_runs (started_at);
+
+		CREATE TABLE IF NOT EXISTS notification_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			check_name TEXT NOT NULL,
+			notifier TEXT NOT NULL,
+			title TEXT NOT NULL,
+			recovery BOOLEAN NOT NULL,
+			sent_at DATETIME NOT NULL,
+			success BOOLEAN NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_notification_attempts_notifier ON notification_attempts (notifier, sent_at);
+		CREATE INDEX IF NOT EXISTS idx_notification_attempts_sent ON notification_attempts (sent_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("create history tables: %w", err)
+	}
+	return nil
+}
+
+// RecordRun logs a single check execution
+func (s *SQLite) RecordRun(run RunRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO check_runs (check_name, started_at, duration_ms, error, should_alert, result_hash)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, run.CheckName, run.StartedAt.UTC(), run.Duration.Milliseconds(), run.Error, run.ShouldAlert, run.ResultHash)
+	if err != nil {
+		return fmt.Errorf("insert check run: %w", err)
+	}
+
+	return nil
+}
+
+// RecordNotification logs a single delivery attempt
+func (s *SQLite) RecordNotification(n NotificationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO notification_attempts (check_name, notifier, title, recovery, sent_at, success, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, n.CheckName, n.Notifier, n.Title, n.Recovery, n.SentAt.UTC(), n.Success, n.Error)
+	if err != nil {
+		return fmt.Errorf("insert notification attempt: %w", err)
+	}
+
+	return nil
+}
+
+// Runs returns check runs matching the query, newest first
+func (s *SQLite) Runs(q RunQuery) ([]RunRecord, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if q.CheckName != "" {
+		where = append(where, "check_name = ?")
+		args = append(args, q.CheckName)
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	if q.FailedOnly {
+		where = append(where, "(error != '' OR should_alert)")
+	}
+
+	query := "SELECT id, check_name, started_at, duration_ms, error, should_alert, result_hash FROM check_runs" +
+		whereClause(where) + " ORDER BY started_at DESC, id DESC" + limitClause(q.Limit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query check runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []RunRecord
+	for rows.Next() {
+		var (
+			r          RunRecord
+			durationMS int64
+		)
+		if err := rows.Scan(&r.ID, &r.CheckName, &r.StartedAt, &durationMS, &r.Error, &r.ShouldAlert, &r.ResultHash); err != nil {
+			return nil, fmt.Errorf("scan check run: %w", err)
+		}
+		r.Duration = time.Duration(durationMS) * time.Millisecond
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query check runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// LastFailure returns the most recent run of a check that errored or alerted
+func (s *SQLite) LastFailure(checkName string) (RunRecord, bool, error) {
+	runs, err := s.Runs(RunQuery{CheckName: checkName, FailedOnly: true, Limit: 1})
+	if err != nil || len(runs) == 0 {
+		return RunRecord{}, false, err
+	}
+	return runs[0], true, nil
+}
+
+// Notifications returns notification attempts matching the query, newest first
+func (s *SQLite) Notifications(q NotificationQuery) ([]NotificationRecord, error) {
+	where, args := notificationFilter(q)
+	query := "SELECT id, check_name, notifier, title, recovery, sent_at, success, error FROM notification_attempts" +
+		whereClause(where) + " ORDER BY sent_at DESC, id DESC" + limitClause(q.Limit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query notification attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []NotificationRecord
+	for rows.Next() {
+		var n NotificationRecord
+		if err := rows.Scan(&n.ID, &n.CheckName, &n.Notifier, &n.Title, &n.Recovery, &n.SentAt, &n.Success, &n.Error); err != nil {
+			return nil, fmt.Errorf("scan notification attempt: %w", err)
+		}
+		attempts = append(attempts, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query notification attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// CountNotifications returns how many notification attempts match the query.
+// The query's Limit is ignored.
+func (s *SQLite) CountNotifications(q NotificationQuery) (int, error) {
+	where, args := notificationFilter(q)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM notification_attempts"+whereClause(where), args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count notification attempts: %w", err)
+	}
+
+	return count, nil
+}
+
+// Prune deletes history rows and expired silences older than the given time
+// and returns how many were removed
+func (s *SQLite) Prune(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+	for _, stmt := range []string{
+		"DELETE FROM check_runs WHERE started_at < ?",
+		"DELETE FROM notification_attempts WHERE sent_at < ?",
+		"DELETE FROM silences WHERE expires_at < ?",
+	} {
+		res, err := s.db.Exec(stmt, before.UTC())
+		if err != nil {
+			return total, fmt.Errorf("prune history: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+
+	return total, nil
+}
+
+// pruneLoop periodically removes history older than the retention period
+// (if positive) and expired cache entries
+func (s *SQLite) pruneLoop(retention time.Duration) {
+	defer close(s.pruneDone)
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if retention > 0 {
+			s.Prune(time.Now().Add(-retention))
+		}
+		s.pruneCache()
+
+		select {
+		case <-s.stopPrune:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notificationFilter builds the WHERE conditions for a notification query
+func notificationFilter(q NotificationQuery) ([]string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	if q.CheckName != "" {
+		where = append(where, "check_name = ?")
+		args = append(args, q.CheckName)
+	}
+	if q.Notifier != "" {
+		where = append(where, "notifier = ?")
+		args = append(args, q.Notifier)
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "sent_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "sent_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	if q.Success != nil {
+		where = append(where, "success = ?")
+		args = append(args, *q.Success)
+	}
+	return where, args
+}
+
+// whereClause joins conditions into a WHERE clause, or "" if there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// limitClause returns a LIMIT clause, or "" for no limit
+func limitClause(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
@@ -0,0 +1,252 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSQLite opens a SQLite state in a temp dir, closed when the test ends
+func openSQLite(t *testing.T, path string, opts ...SQLiteOption) *SQLite {
+	t.Helper()
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "state.db")
+	}
+	s, err := NewSQLite(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteRuns(t *testing.T) {
+	s := openSQLite(t, "")
+	now := time.Now().Truncate(time.Millisecond)
+
+	runs := []RunRecord{
+		{CheckName: "api", StartedAt: now.Add(-3 * time.Minute), Duration: 120 * time.Millisecond},
+		{CheckName: "api", StartedAt: now.Add(-2 * time.Minute), Duration: time.Second, Error: "timeout"},
+		{CheckName: "db", StartedAt: now.Add(-2 * time.Minute), ShouldAlert: true, ResultHash: "abc"},
+		{CheckName: "api", StartedAt: now.Add(-time.Minute), ShouldAlert: true, ResultHash: "def"},
+		{CheckName: "api", StartedAt: now},
+	}
+	for _, r := range runs {
+		if err := s.RecordRun(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query RunQuery
+		want  []time.Time // StartedAt of the expected runs, in order
+	}{
+		{"all of a check", RunQuery{CheckName: "api"}, []time.Time{runs[4].StartedAt, runs[3].StartedAt, runs[1].StartedAt, runs[0].StartedAt}},
+		{"limit", RunQuery{CheckName: "api", Limit: 2}, []time.Time{runs[4].StartedAt, runs[3].StartedAt}},
+		{"failed only", RunQuery{CheckName: "api", FailedOnly: true}, []time.Time{runs[3].StartedAt, runs[1].StartedAt}},
+		{"time range", RunQuery{Since: now.Add(-2 * time.Minute), Until: now}, []time.Time{runs[3].StartedAt, runs[2].StartedAt, runs[1].StartedAt}},
+		{"unknown check", RunQuery{CheckName: "web"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Runs(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d runs, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, r := range got {
+				if !r.StartedAt.Equal(tt.want[i]) {
+					t.Errorf("run %d started at %s, want %s", i, r.StartedAt, tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, _ := s.Runs(RunQuery{CheckName: "api", Limit: 4})
+	if r := got[2]; r.Duration != time.Second || r.Error != "timeout" || !r.Failed() || r.ID == 0 {
+		t.Errorf("run = %+v, want the recorded timeout", r)
+	}
+}
+
+func TestSQLiteLastFailure(t *testing.T) {
+	s := openSQLite(t, "")
+	now := time.Now()
+
+	if _, ok, err := s.LastFailure("api"); ok || err != nil {
+		t.Fatalf("LastFailure without runs = (%t, %v)", ok, err)
+	}
+
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: now.Add(-2 * time.Minute), Error: "refused"})
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: now.Add(-time.Minute), ShouldAlert: true, ResultHash: "abc"})
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: now})
+
+	run, ok, err := s.LastFailure("api")
+	if err != nil || !ok {
+		t.Fatalf("LastFailure = (%t, %v)", ok, err)
+	}
+	if run.ResultHash != "abc" {
+		t.Errorf("last failure = %+v, want the alerting run", run)
+	}
+}
+
+func TestSQLiteNotifications(t *testing.T) {
+	s := openSQLite(t, "")
+	now := time.Now().Truncate(time.Millisecond)
+
+	attempts := []NotificationRecord{
+		{CheckName: "api", Notifier: "slack", Title: "Down", SentAt: now.Add(-3 * time.Minute), Success: true},
+		{CheckName: "api", Notifier: "ntfy", Title: "Down", SentAt: now.Add(-3 * time.Minute), Error: "ntfy returned status 503"},
+		{CheckName: "db", Notifier: "slack", Title: "Slow", SentAt: now.Add(-time.Minute), Success: true},
+		{CheckName: "api", Notifier: "slack", Title: "Resolved", Recovery: true, SentAt: now, Success: true},
+	}
+	for _, n := range attempts {
+		if err := s.RecordNotification(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	succeeded, failed := true, false
+	tests := []struct {
+		name  string
+		query NotificationQuery
+		want  []string // Titles of the expected attempts, in order
+		count int      // CountNotifications, which ignores the limit
+	}{
+		{"all", NotificationQuery{}, []string{"Resolved", "Slow", "Down", "Down"}, 4},
+		{"by check and notifier", NotificationQuery{CheckName: "api", Notifier: "slack"}, []string{"Resolved", "Down"}, 2},
+		{"failed", NotificationQuery{Success: &failed}, []string{"Down"}, 1},
+		{"succeeded since", NotificationQuery{Success: &succeeded, Since: now.Add(-2 * time.Minute)}, []string{"Resolved", "Slow"}, 2},
+		{"until", NotificationQuery{Until: now.Add(-2 * time.Minute)}, []string{"Down", "Down"}, 2},
+		{"limit", NotificationQuery{Limit: 1}, []string{"Resolved"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Notifications(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, n := range got {
+				titles = append(titles, n.Title)
+			}
+			if len(titles) != len(tt.want) {
+				t.Fatalf("titles = %v, want %v", titles, tt.want)
+			}
+			for i := range titles {
+				if titles[i] != tt.want[i] {
+					t.Fatalf("titles = %v, want %v", titles, tt.want)
+				}
+			}
+
+			count, err := s.CountNotifications(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+		})
+	}
+
+	got, _ := s.Notifications(NotificationQuery{Notifier: "ntfy"})
+	if n := got[0]; n.Success || n.Error != "ntfy returned status 503" || n.Recovery {
+		t.Errorf("attempt = %+v, want the recorded failure", n)
+	}
+	got, _ = s.Notifications(NotificationQuery{Limit: 1})
+	if !got[0].Recovery {
+		t.Errorf("attempt = %+v, want a recovery", got[0])
+	}
+}
+
+func TestSQLitePrune(t *testing.T) {
+	s := openSQLite(t, "")
+	now := time.Now()
+
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: now.Add(-48 * time.Hour)})
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: now})
+	s.RecordNotification(NotificationRecord{CheckName: "api", Notifier: "slack", SentAt: now.Add(-48 * time.Hour)})
+	s.RecordNotification(NotificationRecord{CheckName: "api", Notifier: "slack", SentAt: now})
+
+	removed, err := s.Prune(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("pruned %d rows, want 2", removed)
+	}
+	if runs, _ := s.Runs(RunQuery{}); len(runs) != 1 {
+		t.Errorf("%d runs left, want 1", len(runs))
+	}
+	if count, _ := s.CountNotifications(NotificationQuery{}); count != 1 {
+		t.Errorf("%d notifications left, want 1", count)
+	}
+}
+
+func TestSQLitePruneLoop(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		wantRuns  int
+	}{
+		{"default retention", 0, 2},
+		{"short retention", 24 * time.Hour, 1},
+		{"keep forever", -1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.db")
+			now := time.Now()
+
+			s, err := NewSQLite(path, WithRetention(-1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.RecordRun(RunRecord{CheckName: "api", StartedAt: now.Add(-2 * DefaultRetention)})
+			s.RecordRun(RunRecord{CheckName: "api", StartedAt: now.Add(-48 * time.Hour)})
+			s.RecordRun(RunRecord{CheckName: "api", StartedAt: now})
+			s.Close()
+
+			// The loop prunes as soon as the state is opened; closing
+			// waits for it to stop
+			s, err = NewSQLite(path, WithRetention(tt.retention))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+
+			s = openSQLite(t, path, WithRetention(-1))
+			runs, err := s.Runs(RunQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(runs) != tt.wantRuns {
+				t.Errorf("%d runs left, want %d", len(runs), tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestSQLiteWithoutPruning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	s := openSQLite(t, path, WithRetention(time.Hour), WithoutPruning())
+	s.RecordRun(RunRecord{CheckName: "api", StartedAt: time.Now().Add(-48 * time.Hour)})
+
+	// A second process, like a CLI command, doesn't prune either
+	other, err := NewSQLite(path, WithRetention(time.Hour), WithoutPruning())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs, _ := s.Runs(RunQuery{}); len(runs) != 1 {
+		t.Errorf("%d runs left, want the old run kept", len(runs))
+	}
+}
@@ -0,0 +1,198 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// createOutboxTables creates the queue of deliveries to retry and the
+// dead-letter table for those given up on
+func createOutboxTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			notifier TEXT NOT NULL,
+			check_name TEXT NOT NULL,
+			payload BLOB NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt DATETIME NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_outbox_next ON outbox (next_attempt);
+
+		CREATE TABLE IF NOT EXISTS dead_letters (
+			id INTEGER PRIMARY KEY,
+			notifier TEXT NOT NULL,
+			check_name TEXT NOT NULL,
+			payload BLOB NOT NULL,
+			attempts INTEGER NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			dead_at DATETIME NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create outbox tables: %w", err)
+	}
+	return nil
+}
+
+// Enqueue stores a delivery to retry
+func (s *SQLite) Enqueue(d Delivery) (int64, error) {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT INTO outbox (notifier, check_name, payload, attempts, next_attempt, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, d.Notifier, d.CheckName, d.Payload, d.Attempts, d.NextAttempt.UTC(), d.LastError, d.CreatedAt.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("enqueue delivery: %w", err)
+	}
+
+	return res.LastInsertId()
+}
+
+// DueDeliveries returns queued deliveries due at now, oldest first
+func (s *SQLite) DueDeliveries(now time.Time, limit int) ([]Delivery, error) {
+	query := `SELECT id, notifier, check_name, payload, attempts, next_attempt, last_error, created_at
+		FROM outbox WHERE next_attempt <= ? ORDER BY id`
+	args := []any{now.UTC()}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var due []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.Notifier, &d.CheckName, &d.Payload, &d.Attempts, &d.NextAttempt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+
+	return due, nil
+}
+
+// Reschedule records another failed attempt
+func (s *SQLite) Reschedule(id int64, attempts int, next time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(
+		"UPDATE outbox SET attempts = ?, next_attempt = ?, last_error = ? WHERE id = ?",
+		attempts, next.UTC(), lastError, id,
+	)
+	if err != nil {
+		return fmt.Errorf("reschedule delivery: %w", err)
+	}
+	return nil
+}
+
+// Delivered removes a delivery that succeeded
+func (s *SQLite) Delivered(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM outbox WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete delivery: %w", err)
+	}
+	return nil
+}
+
+// DeadLetter moves a delivery to the dead-letter table
+func (s *SQLite) DeadLetter(id int64, attempts int, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("dead-letter delivery: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO dead_letters (id, notifier, check_name, payload, attempts, last_error, created_at, dead_at)
+		SELECT id, notifier, check_name, payload, ?, ?, created_at, ? FROM outbox WHERE id = ?
+	`, attempts, lastError, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("dead-letter delivery: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM outbox WHERE id = ?", id); err != nil {
+		return fmt.Errorf("dead-letter delivery: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// CancelDeliveries removes a check's queued deliveries
+func (s *SQLite) CancelDeliveries(checkName string, notifier string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(
+		"DELETE FROM outbox WHERE check_name = ? AND (? = '' OR notifier = ?)",
+		checkName, notifier, notifier,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("cancel deliveries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cancel deliveries: %w", err)
+	}
+	return int(n), nil
+}
+
+// DeadLetters returns deliveries that were given up on, newest first
+func (s *SQLite) DeadLetters(limit int) ([]Delivery, error) {
+	query := `SELECT id, notifier, check_name, payload, attempts, last_error, created_at
+		FROM dead_letters ORDER BY dead_at DESC, id DESC`
+	var args []any
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.Notifier, &d.CheckName, &d.Payload, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan dead letter: %w", err)
+		}
+		letters = append(letters, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query dead letters: %w", err)
+	}
+
+	return letters, nil
+}
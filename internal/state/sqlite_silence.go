@@ -0,0 +1,142 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createSilenceTable creates the silences table
+func createSilenceTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS silences (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			check_glob TEXT NOT NULL DEFAULT '',
+			tags TEXT NOT NULL DEFAULT '',
+			comment TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_silences_expires ON silences (expires_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("create silences table: %w", err)
+	}
+	return nil
+}
+
+// AddSilence stores a silence and returns its ID
+func (s *SQLite) AddSilence(silence Silence) (int64, error) {
+	if err := silence.Validate(); err != nil {
+		return 0, err
+	}
+	if silence.CreatedAt.IsZero() {
+		silence.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT INTO silences (check_glob, tags, comment, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, silence.CheckGlob, strings.Join(silence.Tags, ","), silence.Comment, silence.CreatedAt.UTC(), silence.ExpiresAt.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("insert silence: %w", err)
+	}
+
+	return res.LastInsertId()
+}
+
+// ListSilences returns silences, newest first
+func (s *SQLite) ListSilences(includeExpired bool) ([]Silence, error) {
+	query := "SELECT id, check_glob, tags, comment, created_at, expires_at FROM silences"
+	var args []any
+	if !includeExpired {
+		query += " WHERE expires_at > ?"
+		args = append(args, time.Now().UTC())
+	}
+	query += " ORDER BY id DESC"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query silences: %w", err)
+	}
+	defer rows.Close()
+
+	var silences []Silence
+	for rows.Next() {
+		var (
+			silence Silence
+			tags    string
+		)
+		if err := rows.Scan(&silence.ID, &silence.CheckGlob, &tags, &silence.Comment, &silence.CreatedAt, &silence.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan silence: %w", err)
+		}
+		if tags != "" {
+			silence.Tags = strings.Split(tags, ",")
+		}
+		silences = append(silences, silence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query silences: %w", err)
+	}
+
+	return silences, nil
+}
+
+// ExpireSilence ends a silence now
+func (s *SQLite) ExpireSilence(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	res, err := s.db.Exec("UPDATE silences SET expires_at = ? WHERE id = ? AND expires_at > ?", now, id, now)
+	if err != nil {
+		return fmt.Errorf("expire silence: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no active silence with id %d", id)
+	}
+
+	return nil
+}
+
+// Silenced returns the first active silence matching a check and its tags
+func (s *SQLite) Silenced(checkName string, tags []string, now time.Time) (Silence, bool) {
+	silences, err := s.ListSilences(false)
+	if err != nil {
+		// On error, err on the side of notifying
+		return Silence{}, false
+	}
+
+	for _, silence := range silences {
+		if silence.Active(now) && silence.Matches(checkName, tags) {
+			return silence, true
+		}
+	}
+	return Silence{}, false
+}
+
+// Acknowledge marks a check's open alert as known
+func (s *SQLite) Acknowledge(checkName, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(
+		"UPDATE alert_state SET acked_at = ?, ack_comment = ? WHERE check_name = ?",
+		time.Now(), comment, checkName,
+	)
+	if err != nil {
+		return fmt.Errorf("acknowledge alert: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s: %w", checkName, ErrNoIncident)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteSilenceExpiry(t *testing.T) {
+	s := openSQLite(t, "")
+	now := time.Now()
+
+	id, err := s.AddSilence(Silence{CheckGlob: "court-*", Comment: "maintenance", ExpiresAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddSilence(Silence{Tags: []string{"db", "web"}, ExpiresAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddSilence(Silence{ExpiresAt: now.Add(time.Hour)}); err == nil {
+		t.Error("added a silence without matchers")
+	}
+
+	if silence, ok := s.Silenced("court-1", nil, now); !ok || silence.ID != id || silence.Comment != "maintenance" {
+		t.Errorf("Silenced(court-1) = %+v, %t, want silence %d", silence, ok, id)
+	}
+	if _, ok := s.Silenced("court-1", nil, now.Add(2*time.Hour)); ok {
+		t.Error("silenced after the silence expired")
+	}
+	if _, ok := s.Silenced("api", []string{"web"}, now); ok {
+		t.Error("silenced by an expired tag silence")
+	}
+
+	active, _ := s.ListSilences(false)
+	all, _ := s.ListSilences(true)
+	if len(active) != 1 || len(all) != 2 {
+		t.Fatalf("listed %d active and %d in all, want 1 and 2", len(active), len(all))
+	}
+	if tags := all[0].Tags; len(tags) != 2 || tags[0] != "db" || tags[1] != "web" {
+		t.Errorf("tags = %v, want [db web]", tags)
+	}
+
+	if err := s.ExpireSilence(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Silenced("court-1", nil, time.Now()); ok {
+		t.Error("silenced after the silence was expired")
+	}
+	if err := s.ExpireSilence(id); err == nil {
+		t.Error("expired a silence twice")
+	}
+
+	// Expired silences are pruned with history
+	if _, err := s.Prune(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := s.ListSilences(true); len(all) != 0 {
+		t.Errorf("%d silences left after pruning, want 0", len(all))
+	}
+}
+
+func TestSQLiteAcknowledge(t *testing.T) {
+	s := openSQLite(t, "")
+
+	if err := s.Acknowledge("api", "on it"); !errors.Is(err, ErrNoIncident) {
+		t.Fatalf("Acknowledge without an alert = %v, want ErrNoIncident", err)
+	}
+
+	s.MarkAlerted("api", "h1", "CRITICAL", "high")
+	if err := s.Acknowledge("api", "on it"); err != nil {
+		t.Fatal(err)
+	}
+	if inc, _ := s.Incident("api"); !inc.Acknowledged() || inc.AckComment != "on it" {
+		t.Errorf("incident = %+v, want acknowledged", inc)
+	}
+
+	// A reminder for the same status keeps the acknowledgement
+	s.MarkAlerted("api", "h1", "CRITICAL", "high")
+	if inc, _ := s.Incident("api"); !inc.Acknowledged() {
+		t.Error("acknowledgement dropped by a reminder")
+	}
+
+	// A status change drops it
+	s.MarkAlerted("api", "h2", "WARNING", "normal")
+	if inc, _ := s.Incident("api"); inc.Acknowledged() || inc.AckComment != "" {
+		t.Errorf("incident = %+v after a status change, want unacknowledged", inc)
+	}
+
+	// So does recovering
+	s.Acknowledge("api", "")
+	s.Clear("api")
+	s.MarkAlerted("api", "h1", "CRITICAL", "high")
+	if inc, _ := s.Incident("api"); inc.Acknowledged() {
+		t.Error("acknowledgement survived recovery")
+	}
+}
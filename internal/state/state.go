@@ -3,18 +3,29 @@ package state
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"slices"
 	"sync"
 	"time"
 )
 
 // State tracks alert state to prevent duplicate notifications
 type State interface {
-	// ShouldAlert returns true if this is a new alert condition
-	ShouldAlert(checkName string, resultHash string) bool
-	// MarkAlerted records that an alert was sent
-	MarkAlerted(checkName string, resultHash string) error
-	// Clear resets state for a check (when condition clears)
+	// MarkAlerted records that an alert was sent for a result with the
+	// given status, routed at the given priority
+	MarkAlerted(checkName string, resultHash string, status string, priority string) error
+	// Incident returns the open alert for a check, if one has been sent and not cleared
+	Incident(checkName string) (Incident, bool)
+	// Clear resets state for a check (when condition clears), including
+	// its per-notifier deliveries
 	Clear(checkName string) error
+	// DeliveredTo returns the notifiers that have delivered the alert
+	// identified by key since the check's deliveries were last reset
+	DeliveredTo(checkName string, key string) []string
+	// MarkDelivered records that a notifier delivered the alert identified by key
+	MarkDelivered(checkName string, notifier string, key string) error
+	// ResetDelivered forgets a check's per-notifier deliveries, once an
+	// alert has reached every notifier
+	ResetDelivered(checkName string) error
 	// Close cleans up resources
 	Close() error
 }
@@ -27,57 +38,149 @@ func Hash(title, message string) string {
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
 
+// Incident describes an open alert condition
+type Incident struct {
+	Hash      string    // Hash of the most recently alerted result
+	Status    string    // Status of the most recently alerted result (e.g. "WARNING")
+	Priority  string    // Priority the status was alerted at, before escalation (e.g. "high")
+	OpenedAt  time.Time // When the condition first alerted
+	AlertedAt time.Time // When the most recent alert was sent
+	Count     int       // Alerts sent for the current status, including reminders
+
+	// AckedAt is when the alert was acknowledged (zero if it wasn't).
+	// Acknowledgements are dropped when the status changes.
+	AckedAt    time.Time
+	AckComment string
+}
+
+// Acknowledged reports whether the open alert has been acknowledged
+func (i Incident) Acknowledged() bool {
+	return !i.AckedAt.IsZero()
+}
+
 // alertRecord tracks when an alert was sent
 type alertRecord struct {
 	hash      string
+	status    string
+	priority  string
+	openedAt  time.Time
 	alertedAt time.Time
+	count     int
 }
 
 // Memory implements in-memory state tracking
 type Memory struct {
 	mu     sync.RWMutex
 	alerts map[string]alertRecord
+	pings  map[string]Heartbeat
+
+	// delivered maps check name to notifier name to the key of the alert
+	// the notifier last delivered
+	delivered map[string]map[string]string
+
+	outbox       []Delivery
+	deadLetters  []Delivery
+	nextDelivery int64
 }
 
 // NewMemory creates a new in-memory state tracker
 func NewMemory() *Memory {
 	return &Memory{
-		alerts: make(map[string]alertRecord),
+		alerts:    make(map[string]alertRecord),
+		pings:     make(map[string]Heartbeat),
+		delivered: make(map[string]map[string]string),
 	}
 }
 
-// ShouldAlert returns true if this hash hasn't been alerted recently
-func (m *Memory) ShouldAlert(checkName string, resultHash string) bool {
+// MarkAlerted records that an alert was sent
+func (m *Memory) MarkAlerted(checkName string, resultHash string, status string, priority string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	record := alertRecord{
+		hash:      resultHash,
+		status:    status,
+		priority:  priority,
+		openedAt:  now,
+		alertedAt: now,
+		count:     1,
+	}
+	if existing, ok := m.alerts[checkName]; ok {
+		record.openedAt = existing.openedAt
+		if existing.status == status {
+			record.count = existing.count + 1
+		}
+	}
+
+	m.alerts[checkName] = record
+
+	return nil
+}
+
+// Incident returns the open alert for a check
+func (m *Memory) Incident(checkName string) (Incident, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	record, exists := m.alerts[checkName]
 	if !exists {
-		return true
+		return Incident{}, false
 	}
 
-	return record.hash != resultHash
+	return Incident{
+		Hash:      record.hash,
+		Status:    record.status,
+		Priority:  record.priority,
+		OpenedAt:  record.openedAt,
+		AlertedAt: record.alertedAt,
+		Count:     record.count,
+	}, true
 }
 
-// MarkAlerted records that an alert was sent
-func (m *Memory) MarkAlerted(checkName string, resultHash string) error {
+// Clear removes state for a check
+func (m *Memory) Clear(checkName string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.alerts[checkName] = alertRecord{
-		hash:      resultHash,
-		alertedAt: time.Now(),
+	delete(m.alerts, checkName)
+	delete(m.delivered, checkName)
+	return nil
+}
+
+// DeliveredTo returns the notifiers that delivered the alert identified by key
+func (m *Memory) DeliveredTo(checkName string, key string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var notifiers []string
+	for notifier, k := range m.delivered[checkName] {
+		if k == key {
+			notifiers = append(notifiers, notifier)
+		}
 	}
+	slices.Sort(notifiers)
+	return notifiers
+}
+
+// MarkDelivered records that a notifier delivered the alert identified by key
+func (m *Memory) MarkDelivered(checkName string, notifier string, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	if m.delivered[checkName] == nil {
+		m.delivered[checkName] = make(map[string]string)
+	}
+	m.delivered[checkName][notifier] = key
 	return nil
 }
 
-// Clear removes state for a check
-func (m *Memory) Clear(checkName string) error {
+// ResetDelivered forgets a check's per-notifier deliveries
+func (m *Memory) ResetDelivered(checkName string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.alerts, checkName)
+	delete(m.delivered, checkName)
 	return nil
 }
 